@@ -8,6 +8,7 @@ import (
 	"orlando-app/internal/database"
 	"orlando-app/internal/handlers"
 	"orlando-app/internal/middleware"
+	"time"
 
 	gorillaHandlers "github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -33,6 +34,10 @@ func main() {
 		log.Fatal("Failed to create tables:", err)
 	}
 
+	if err := db.CreateSearchIndex(); err != nil {
+		log.Fatal("Failed to create search index:", err)
+	}
+
 	// Load challenges from CSV file
 	if err := db.LoadChallengesFromCSV("challenges.csv"); err != nil {
 		log.Printf("Warning: Failed to load challenges from CSV: %v", err)
@@ -47,16 +52,21 @@ func main() {
 
 	h := handlers.NewHandler(db.DB, cfg)
 
+	// Background jobs for the challenge lifecycle (expiring claims etc.)
+	go h.RunScheduler(time.Duration(cfg.SchedulerIntervalSeconds) * time.Second)
+
 	r := mux.NewRouter()
+	r.Use(middleware.RequestIDMiddleware)
+	r.Use(middleware.ClientIPMiddleware(cfg))
 
 	// CORS configuration from environment
 	log.Printf("🌐 CORS Origins: %v", cfg.AllowedOrigins)
 	corsHandler := gorillaHandlers.CORS(
 		gorillaHandlers.AllowedOrigins(cfg.AllowedOrigins),
 		gorillaHandlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "HEAD"}),
-		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin"}),
+		gorillaHandlers.AllowedHeaders([]string{"Content-Type", "Authorization", "X-Requested-With", "Accept", "Origin", "X-Request-ID"}),
 		gorillaHandlers.AllowCredentials(),
-		gorillaHandlers.ExposedHeaders([]string{"Content-Length", "Content-Type"}),
+		gorillaHandlers.ExposedHeaders([]string{"Content-Length", "Content-Type", "X-Request-ID"}),
 	)
 
 	// Auth routes (no auth required)
@@ -71,13 +81,33 @@ func main() {
 	protected.HandleFunc("/users/profile", h.GetProfile).Methods("GET")
 	protected.HandleFunc("/users/profile", h.UpdateProfile).Methods("PUT")
 	protected.HandleFunc("/users/{id}", h.GetUser).Methods("GET")
+	protected.HandleFunc("/users/{id}/posts", h.GetUserPosts).Methods("GET")
+	protected.HandleFunc("/users/{id}/follow", h.FollowUser).Methods("POST")
+	protected.HandleFunc("/users/{id}/follow", h.UnfollowUser).Methods("DELETE")
+	protected.HandleFunc("/users/{id}/followers", h.GetFollowers).Methods("GET")
+	protected.HandleFunc("/users/{id}/following", h.GetFollowing).Methods("GET")
 
 	// Challenge routes
 	protected.HandleFunc("/challenges", h.GetChallenges).Methods("GET")
+	protected.HandleFunc("/challenges/unlock", h.UnlockChallenge).Methods("POST")
 	protected.HandleFunc("/challenges/{id}", h.GetChallenge).Methods("GET")
 	protected.HandleFunc("/challenges/{id}/pick", h.PickChallenge).Methods("POST")
 	protected.HandleFunc("/challenges/{id}/cancel", h.CancelChallenge).Methods("POST")
+	protected.HandleFunc("/challenges/{id}/extend", h.ExtendClaim).Methods("POST")
 	protected.HandleFunc("/challenges/{id}/complete", h.CompleteChallenge).Methods("POST")
+	protected.HandleFunc("/challenges/{id}/vote", h.VoteChallenge).Methods("POST")
+	protected.HandleFunc("/challenges/{id}/votes", h.GetChallengeVotes).Methods("GET")
+
+	// Completion verification routes
+	protected.HandleFunc("/verifications", h.GetPendingVerifications).Methods("GET")
+	protected.HandleFunc("/posts/{id}/review", h.ReviewCompletion).Methods("POST")
+
+	// Search routes
+	protected.HandleFunc("/search", h.Search).Methods("GET")
+
+	// Notification routes
+	protected.HandleFunc("/notifications", h.GetNotifications).Methods("GET")
+	protected.HandleFunc("/notifications/read", h.MarkNotificationsRead).Methods("POST")
 
 	// Media upload routes
 	protected.HandleFunc("/media/upload", h.UploadMedia).Methods("POST")
@@ -91,20 +121,39 @@ func main() {
 	admin.HandleFunc("/challenges/{id}", h.DeleteChallenge).Methods("DELETE")
 	admin.HandleFunc("/challenges/{id}/unassign", h.UnassignChallenge).Methods("POST")
 	admin.HandleFunc("/challenges/{id}/award", h.AwardChallenge).Methods("POST")
+	admin.HandleFunc("/unlocks", h.GetChallengeUnlocks).Methods("GET")
 	admin.HandleFunc("/posts/{id}/revoke", h.RevokePostPoints).Methods("POST")
+	admin.HandleFunc("/posts/{id}/restore", h.RestorePost).Methods("POST")
+	admin.HandleFunc("/moderation", h.GetModerationQueue).Methods("GET")
+	admin.HandleFunc("/posts/{id}/moderate", h.ModeratePost).Methods("POST")
+	admin.HandleFunc("/audit", h.GetAuditLog).Methods("GET")
+	admin.HandleFunc("/reports", h.GetReports).Methods("GET")
+	admin.HandleFunc("/posts/{id}/reports/resolve", h.ResolvePostReports).Methods("POST")
+	admin.HandleFunc("/comments/{id}/reports/resolve", h.ResolveCommentReports).Methods("POST")
 
 	// Feed routes
 	feedRouter := r.PathPrefix("/feed").Subrouter()
 	feedRouter.Use(middleware.OptionalAuthMiddleware(db.DB, cfg))
 	feedRouter.HandleFunc("", h.GetFeed).Methods("GET")
+	feedRouter.HandleFunc("/tags/trending", h.GetTrendingTags).Methods("GET")
+	feedRouter.HandleFunc("/top", h.GetTopPosts).Methods("GET")
 
 	// Post routes
 	protected.HandleFunc("/posts/{id}", h.GetPost).Methods("GET")
 	protected.HandleFunc("/posts/{id}", h.DeletePost).Methods("DELETE")
 	protected.HandleFunc("/posts/{id}/like", h.LikePost).Methods("POST")
 	protected.HandleFunc("/posts/{id}/like", h.UnlikePost).Methods("DELETE")
+	protected.HandleFunc("/posts/{id}/likes", h.GetPostReactions).Methods("GET")
+	protected.HandleFunc("/posts/{id}/reaction", h.SetReaction).Methods("PUT")
+	protected.HandleFunc("/posts/{id}/reaction", h.UnlikePost).Methods("DELETE")
 	protected.HandleFunc("/posts/{id}/comments", h.GetComments).Methods("GET")
 	protected.HandleFunc("/posts/{id}/comments", h.CreateComment).Methods("POST")
+	protected.HandleFunc("/posts/{id}/report", h.ReportPost).Methods("POST")
+	protected.HandleFunc("/comments/{id}", h.UpdateComment).Methods("PUT")
+	protected.HandleFunc("/comments/{id}", h.DeleteComment).Methods("DELETE")
+	protected.HandleFunc("/comments/{id}/history", h.GetCommentHistory).Methods("GET")
+	protected.HandleFunc("/comments/{id}/replies", h.GetCommentReplies).Methods("GET")
+	protected.HandleFunc("/comments/{id}/report", h.ReportComment).Methods("POST")
 
 	// Leaderboard routes (no auth required)
 	r.HandleFunc("/leaderboard", h.GetLeaderboard).Methods("GET")
@@ -24,6 +24,9 @@ type Config struct {
 	// CORS configuration
 	AllowedOrigins []string
 	
+	// Reverse proxies (IPs or CIDR ranges) whose X-Real-IP header is trusted
+	TrustedProxies []string
+	
 	// File upload configuration
 	UploadPath     string
 	MaxFileSize    int64 // in bytes
@@ -35,6 +38,27 @@ type Config struct {
 	
 	// Logging
 	LogLevel string // debug, info, warn, error
+	
+	// Challenge lifecycle
+	ChallengeGraceMinutes    int // how long after end_date a held challenge can still be completed
+	SchedulerIntervalSeconds int // how often background lifecycle jobs run
+	ClaimTTLMinutes          int // default time an exclusive claim is held before release, 0 disables
+	ClaimExtensionMinutes    int // how long a single claim extension adds
+	AllowClaimExtension      bool
+	MaxHeldChallenges        int // how many challenges a user can hold at once, 0 disables the limit
+	UnlockMaxAttempts        int // wrong secret codes a user can enter per window
+	UnlockWindowMinutes      int
+	VerificationApprovals      int     // peer approvals that verify a completion
+	VerificationTimeoutMinutes int     // how long a completion waits for review, 0 waits forever
+	VerificationTimeoutAction  string  // approve or escalate completions nobody reviewed in time
+	ReportHideThreshold        int     // open reports that hide a post or comment until an admin resolves them, 0 disables
+	CommentEditWindowMinutes   int     // how long after posting a comment can be edited, 0 removes the limit
+	TrendingWindowHours        int     // how far back trending hashtags are counted
+	RankReactionWeight         float64 // ranked feed score of each reaction on a post
+	RankCommentWeight          float64 // ranked feed score of each comment on a post
+	RankPointsWeight           float64 // ranked feed score of each challenge point a post is worth
+	RankGravity                float64 // how fast ranked posts sink with age, 0 turns decay off
+	TripTimezone               string  // where "today" starts and ends for top posts
 }
 
 func Load() *Config {
@@ -57,6 +81,9 @@ func Load() *Config {
 		// CORS defaults
 		AllowedOrigins: getEnvAsSlice("ALLOWED_ORIGINS", []string{"http://localhost:3000", "http://localhost:8081"}),
 		
+		// Proxy defaults: trust no one, so X-Real-IP is ignored
+		TrustedProxies: getEnvAsSlice("TRUSTED_PROXIES", []string{}),
+		
 		// File upload defaults
 		UploadPath:   getEnv("UPLOAD_PATH", "./uploads"),
 		MaxFileSize:  getEnvAsInt64("MAX_FILE_SIZE", 50*1024*1024), // 50MB default
@@ -68,6 +95,27 @@ func Load() *Config {
 		
 		// Logging defaults
 		LogLevel: getEnv("LOG_LEVEL", "info"),
+		
+		// Challenge lifecycle defaults
+		ChallengeGraceMinutes:    getEnvAsInt("CHALLENGE_GRACE_MINUTES", 30),
+		SchedulerIntervalSeconds: getEnvAsInt("SCHEDULER_INTERVAL_SECONDS", 60),
+		ClaimTTLMinutes:          getEnvAsInt("CLAIM_TTL_MINUTES", 0),
+		ClaimExtensionMinutes:    getEnvAsInt("CLAIM_EXTENSION_MINUTES", 30),
+		AllowClaimExtension:      getEnvAsBool("ALLOW_CLAIM_EXTENSION", true),
+		MaxHeldChallenges:        getEnvAsInt("MAX_HELD_CHALLENGES", 0),
+		UnlockMaxAttempts:        getEnvAsInt("UNLOCK_MAX_ATTEMPTS", 5),
+		UnlockWindowMinutes:      getEnvAsInt("UNLOCK_WINDOW_MINUTES", 15),
+		VerificationApprovals:      getEnvAsInt("VERIFICATION_APPROVALS", 2),
+		VerificationTimeoutMinutes: getEnvAsInt("VERIFICATION_TIMEOUT_MINUTES", 1440),
+		VerificationTimeoutAction:  getEnv("VERIFICATION_TIMEOUT_ACTION", "approve"),
+		ReportHideThreshold:        getEnvAsInt("REPORT_HIDE_THRESHOLD", 3),
+		CommentEditWindowMinutes:   getEnvAsInt("COMMENT_EDIT_WINDOW_MINUTES", 15),
+		TrendingWindowHours:        getEnvAsInt("TRENDING_WINDOW_HOURS", 24),
+		RankReactionWeight:         getEnvAsFloat("RANK_REACTION_WEIGHT", 1),
+		RankCommentWeight:          getEnvAsFloat("RANK_COMMENT_WEIGHT", 2),
+		RankPointsWeight:           getEnvAsFloat("RANK_POINTS_WEIGHT", 0.5),
+		RankGravity:                getEnvAsFloat("RANK_GRAVITY", 1.5),
+		TripTimezone:               getEnv("TRIP_TIMEZONE", "America/New_York"),
 	}
 	
 	// Validate critical configuration
@@ -121,6 +169,34 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 	return value
 }
 
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		log.Printf("Invalid float value for %s: %s, using default: %g", key, valueStr, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		log.Printf("Invalid boolean value for %s: %s, using default: %t", key, valueStr, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
 func getEnvAsSlice(key string, defaultValue []string) []string {
 	valueStr := getEnv(key, "")
 	if valueStr == "" {
@@ -5,8 +5,10 @@ import (
 	"encoding/csv"
 	"fmt"
 	"log"
+	"orlando-app/internal/tags"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -119,14 +121,217 @@ func (db *DB) CreateTables() error {
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 			UNIQUE(challenge_id, user_id)
 		);`,
+		`ALTER TABLE challenges ADD COLUMN geofence_lat REAL;`,
+		`ALTER TABLE challenges ADD COLUMN geofence_lon REAL;`,
+		`ALTER TABLE challenges ADD COLUMN geofence_radius_m REAL;`,
+		`ALTER TABLE challenges ADD COLUMN geofence_park TEXT;`,
+		`ALTER TABLE posts ADD COLUMN location_status TEXT;`,
+		`ALTER TABLE temp_media ADD COLUMN gps_lat REAL;`,
+		`ALTER TABLE temp_media ADD COLUMN gps_lon REAL;`,
+		`CREATE TABLE IF NOT EXISTS notifications (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER REFERENCES users(id),
+			kind TEXT NOT NULL,
+			message TEXT NOT NULL,
+			challenge_id INTEGER REFERENCES challenges(id),
+			post_id INTEGER REFERENCES posts(id),
+			read_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at);`,
+		`ALTER TABLE challenges ADD COLUMN claim_ttl_minutes INTEGER;`,
+		`ALTER TABLE challenges ADD COLUMN claim_expires_at TIMESTAMP;`,
+		`ALTER TABLE challenges ADD COLUMN claim_extended BOOLEAN DEFAULT FALSE;`,
+		`ALTER TABLE challenges ADD COLUMN category TEXT;`,
+		`CREATE TABLE IF NOT EXISTS challenge_tags (
+			challenge_id INTEGER REFERENCES challenges(id),
+			tag TEXT NOT NULL,
+			PRIMARY KEY (challenge_id, tag)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_challenge_tags_tag ON challenge_tags(tag);`,
+		`ALTER TABLE challenges ADD COLUMN min_points INTEGER;`,
+		`ALTER TABLE challenges ADD COLUMN show_when_locked BOOLEAN DEFAULT FALSE;`,
+		`CREATE TABLE IF NOT EXISTS challenge_prerequisites (
+			challenge_id INTEGER REFERENCES challenges(id),
+			required_challenge_id INTEGER REFERENCES challenges(id),
+			PRIMARY KEY (challenge_id, required_challenge_id)
+		);`,
+		`ALTER TABLE challenges ADD COLUMN hidden BOOLEAN DEFAULT FALSE;`,
+		`ALTER TABLE challenges ADD COLUMN unlock_code TEXT;`,
+		`ALTER TABLE challenges ADD COLUMN unlock_scope TEXT;`,
+		`ALTER TABLE challenges ADD COLUMN unlocked_at TIMESTAMP;`,
+		`CREATE INDEX IF NOT EXISTS idx_challenges_unlock_code ON challenges(unlock_code);`,
+		`CREATE TABLE IF NOT EXISTS challenge_unlocks (
+			challenge_id INTEGER REFERENCES challenges(id),
+			user_id INTEGER REFERENCES users(id),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (challenge_id, user_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS unlock_attempts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER REFERENCES users(id),
+			success BOOLEAN NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_unlock_attempts_user_id ON unlock_attempts(user_id, created_at);`,
+		`ALTER TABLE challenges ADD COLUMN placement_points TEXT;`,
+		`ALTER TABLE challenges ADD COLUMN participation_points INTEGER DEFAULT 0;`,
+		`CREATE TABLE IF NOT EXISTS challenge_placements (
+			challenge_id INTEGER REFERENCES challenges(id),
+			user_id INTEGER REFERENCES users(id),
+			post_id INTEGER REFERENCES posts(id),
+			place INTEGER,
+			points INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (challenge_id, user_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_challenge_placements_post_id ON challenge_placements(post_id);`,
+		// Open challenges awarded before placements existed credit their single winner
+		`INSERT INTO challenge_placements (challenge_id, user_id, post_id, place, points)
+			SELECT c.id, c.completed_by, c.completed_post_id, 1, c.points FROM challenges c
+			WHERE c.challenge_type = 'open' AND c.status = 'completed'
+			AND c.completed_by IS NOT NULL AND c.completed_post_id IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM challenge_placements cpl WHERE cpl.challenge_id = c.id);`,
+		`ALTER TABLE challenges ADD COLUMN voting_ends_at TIMESTAMP;`,
+		`ALTER TABLE challenges ADD COLUMN voting_closed_at TIMESTAMP;`,
+		`CREATE TABLE IF NOT EXISTS challenge_votes (
+			challenge_id INTEGER REFERENCES challenges(id),
+			voter_id INTEGER REFERENCES users(id),
+			post_id INTEGER REFERENCES posts(id),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (challenge_id, voter_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_challenge_votes_post_id ON challenge_votes(post_id);`,
+		`ALTER TABLE challenges ADD COLUMN requires_verification BOOLEAN DEFAULT FALSE;`,
+		`ALTER TABLE challenges ADD COLUMN verification_due_at TIMESTAMP;`,
+		`ALTER TABLE challenges ADD COLUMN verification_escalated BOOLEAN DEFAULT FALSE;`,
+		`CREATE TABLE IF NOT EXISTS completion_reviews (
+			post_id INTEGER REFERENCES posts(id),
+			reviewer_id INTEGER REFERENCES users(id),
+			challenge_id INTEGER REFERENCES challenges(id),
+			approved BOOLEAN NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (post_id, reviewer_id)
+		);`,
+		`ALTER TABLE posts ADD COLUMN media_hash TEXT;`,
+		`CREATE INDEX IF NOT EXISTS idx_posts_media_hash ON posts(media_hash);`,
+		`ALTER TABLE posts ADD COLUMN moderation_status TEXT;`,
+		`CREATE TABLE IF NOT EXISTS post_reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER REFERENCES posts(id),
+			reporter_id INTEGER REFERENCES users(id),
+			reason TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			resolved_at TIMESTAMP,
+			UNIQUE(post_id, reporter_id)
+		);`,
+		`CREATE TABLE IF NOT EXISTS moderation_actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER REFERENCES posts(id),
+			moderator_id INTEGER REFERENCES users(id),
+			action TEXT NOT NULL,
+			reason TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_moderation_actions_post_id ON moderation_actions(post_id);`,
+		`ALTER TABLE posts ADD COLUMN revoke_reason TEXT;`,
+		`ALTER TABLE posts ADD COLUMN revoked_at TIMESTAMP;`,
+		`CREATE TABLE IF NOT EXISTS audit_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			actor_id INTEGER REFERENCES users(id),
+			action TEXT NOT NULL,
+			target_type TEXT NOT NULL,
+			target_id INTEGER NOT NULL,
+			before_state TEXT,
+			after_state TEXT,
+			ip TEXT,
+			request_id TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);`,
+		`ALTER TABLE post_reports ADD COLUMN details TEXT;`,
+		`CREATE TABLE IF NOT EXISTS comment_reports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			comment_id INTEGER REFERENCES comments(id),
+			reporter_id INTEGER REFERENCES users(id),
+			reason TEXT NOT NULL,
+			details TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			resolved_at TIMESTAMP,
+			UNIQUE(comment_id, reporter_id)
+		);`,
+		`ALTER TABLE posts ADD COLUMN removed_at TIMESTAMP;`,
+		`ALTER TABLE comments ADD COLUMN removed_at TIMESTAMP;`,
+		`ALTER TABLE comments ADD COLUMN edited_at TIMESTAMP;`,
+		`ALTER TABLE comments ADD COLUMN deleted_at TIMESTAMP;`,
+		`ALTER TABLE comments ADD COLUMN deleted_by INTEGER REFERENCES users(id);`,
+		`CREATE TABLE IF NOT EXISTS comment_edits (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			comment_id INTEGER REFERENCES comments(id),
+			content TEXT NOT NULL,
+			edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_comment_edits_comment_id ON comment_edits(comment_id);`,
+		`ALTER TABLE comments ADD COLUMN parent_id INTEGER REFERENCES comments(id);`,
+		`ALTER TABLE comments ADD COLUMN depth INTEGER DEFAULT 0;`,
+		`CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);`,
+		`CREATE TABLE IF NOT EXISTS mentions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER REFERENCES posts(id),
+			comment_id INTEGER REFERENCES comments(id),
+			user_id INTEGER REFERENCES users(id),
+			start_pos INTEGER NOT NULL,
+			end_pos INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_mentions_post_id ON mentions(post_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_mentions_comment_id ON mentions(comment_id);`,
+		`CREATE TABLE IF NOT EXISTS hashtags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			post_id INTEGER REFERENCES posts(id),
+			comment_id INTEGER REFERENCES comments(id),
+			tag TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_hashtags_tag ON hashtags(tag, created_at);`,
+		`CREATE INDEX IF NOT EXISTS idx_hashtags_post_id ON hashtags(post_id);`,
+		`CREATE INDEX IF NOT EXISTS idx_hashtags_comment_id ON hashtags(comment_id);`,
+		`CREATE TABLE IF NOT EXISTS reactions (
+			user_id INTEGER REFERENCES users(id),
+			post_id INTEGER REFERENCES posts(id),
+			reaction TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (user_id, post_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_reactions_post_id ON reactions(post_id);`,
+		`CREATE TABLE IF NOT EXISTS follows (
+			follower_id INTEGER REFERENCES users(id),
+			followee_id INTEGER REFERENCES users(id),
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (follower_id, followee_id)
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows(followee_id);`,
+		`CREATE TABLE IF NOT EXISTS post_scores (
+			post_id INTEGER PRIMARY KEY REFERENCES posts(id),
+			engagement REAL NOT NULL,
+			rank REAL NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);`,
+		// likes is legacy: nothing writes to it any more and it is only read
+		// here, once, to turn likes from before reactions into hearts. SQLite
+		// never recorded when they were left, so their time stays unknown.
+		// Copied likes are cleared so a reaction removed later doesn't come
+		// back on the next start.
+		`INSERT OR IGNORE INTO reactions (user_id, post_id, reaction, created_at) SELECT user_id, post_id, 'heart', NULL FROM likes;`,
+		`DELETE FROM likes WHERE EXISTS (SELECT 1 FROM reactions r WHERE r.user_id = likes.user_id AND r.post_id = likes.post_id);`,
 	}
 
 	for _, query := range migrationQueries {
 		if _, err := db.Exec(query); err != nil {
 			// Ignore errors for columns that already exist
-			if err.Error() != "duplicate column name: start_date" && 
-			   err.Error() != "duplicate column name: end_date" &&
-			   err.Error() != "duplicate column name: revoked" {
+			if !strings.HasPrefix(err.Error(), "duplicate column name") {
 				log.Printf("Migration warning: %v", err)
 			}
 		}
@@ -184,6 +389,8 @@ func (db *DB) LoadChallengesFromCSV(csvPath string) error {
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	// category and tags columns are optional
+	reader.FieldsPerRecord = -1
 	records, err := reader.ReadAll()
 	if err != nil {
 		return fmt.Errorf("failed to read CSV: %v", err)
@@ -207,6 +414,22 @@ func (db *DB) LoadChallengesFromCSV(csvPath string) error {
 		startDateStr := record[4]
 		endDateStr := record[5]
 
+		// Optional category and semicolon-separated tags, e.g. "park:Epcot;media:photo"
+		var category *string
+		if len(record) > 6 {
+			if normalized := tags.NormalizeCategory(record[6]); normalized != "" {
+				category = &normalized
+			}
+		}
+		var challengeTags []string
+		if len(record) > 7 {
+			challengeTags, err = tags.NormalizeAll(tags.Parse(record[7], ";"))
+			if err != nil {
+				log.Printf("Warning: %v for challenge '%s', ignoring tags", err, title)
+				challengeTags = nil
+			}
+		}
+
 		// Parse points
 		points, err := strconv.Atoi(pointsStr)
 		if err != nil {
@@ -237,15 +460,23 @@ func (db *DB) LoadChallengesFromCSV(csvPath string) error {
 		}
 
 		// Insert challenge into database
-		_, err = db.Exec(`
-			INSERT INTO challenges (title, description, points, challenge_type, start_date, end_date, status)
-			VALUES (?, ?, ?, ?, ?, ?, 'available')
-		`, title, description, points, challengeType, startDate, endDate)
+		var challengeID int
+		err = db.QueryRow(`
+			INSERT INTO challenges (title, description, points, challenge_type, start_date, end_date, status, category)
+			VALUES (?, ?, ?, ?, ?, ?, 'available', ?)
+			RETURNING id
+		`, title, description, points, challengeType, startDate, endDate, category).Scan(&challengeID)
 
 		if err != nil {
 			log.Printf("Failed to insert challenge '%s': %v", title, err)
 			continue
 		}
+
+		for _, tag := range challengeTags {
+			if _, err := db.Exec(`INSERT INTO challenge_tags (challenge_id, tag) VALUES (?, ?)`, challengeID, tag); err != nil {
+				log.Printf("Failed to tag challenge '%s' with '%s': %v", title, tag, err)
+			}
+		}
 	}
 
 	log.Printf("Successfully loaded %d challenges from CSV", len(records)-1)
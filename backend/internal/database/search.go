@@ -0,0 +1,148 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// searchSources describes what goes into the search index for each kind of
+// row. Index rows are keyed by rowid = id*4 + offset so triggers can find
+// them without scanning the index.
+var searchSources = []struct {
+	kind   string
+	table  string
+	offset int
+	title  string // indexed expressions over the row, aliased as r
+	body   string
+	fields string // the columns whose change refreshes the index row
+}{
+	{"challenge", "challenges", 0, "r.title", "r.description", "title, description"},
+	{"post", "posts", 1, "''", "COALESCE(r.caption, '')", "caption"},
+	{"comment", "comments", 2, "''", "r.content", "content"},
+	{"user", "users", 3, "r.username", "r.first_name || ' ' || r.last_name", "username, first_name, last_name"},
+}
+
+// searchTriggers are the suffixes of the triggers kept on each source table
+var searchTriggers = []string{"insert", "update", "delete"}
+
+// searchIndexVersion is bumped whenever searchSources or the index columns
+// change, so the next start rebuilds the index
+const searchIndexVersion = 1
+
+// dropSearchTriggers removes the triggers that keep the index in sync
+func dropSearchTriggers(tx *sql.Tx) error {
+	for _, source := range searchSources {
+		for _, trigger := range searchTriggers {
+			if _, err := tx.Exec(`DROP TRIGGER IF EXISTS search_` + source.table + `_` + trigger); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchIndexCurrent reports whether the index exists at the current version
+// with all its triggers in place. Without the triggers, writes may have
+// been missed and the index is rebuilt.
+func (db *DB) searchIndexCurrent() (bool, error) {
+	var current bool
+	err := db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'search_index')
+			AND EXISTS (SELECT 1 FROM search_index_version WHERE version = ?)
+			AND (SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'search\_%' ESCAPE '\') = ?
+	`, searchIndexVersion, len(searchSources)*len(searchTriggers)).Scan(&current)
+	return current, err
+}
+
+// CreateSearchIndex sets up the FTS5 index behind /search, with triggers that
+// keep it in sync. The index is only rebuilt from the current rows when it is
+// new, out of date or has lost its triggers. SQLite needs to be built with
+// the sqlite_fts5 tag; without it search is left disabled and triggers left
+// by an earlier FTS5 build are dropped, since every write they fire on would
+// fail without the module.
+func (db *DB) CreateSearchIndex() error {
+	var fts5 bool
+	if err := db.QueryRow(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return err
+	}
+	if !fts5 {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := dropSearchTriggers(tx); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("Search disabled: SQLite was built without FTS5 (build with -tags sqlite_fts5)")
+		return nil
+	}
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS search_index_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+	current, err := db.searchIndexCurrent()
+	if err != nil || current {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := dropSearchTriggers(tx); err != nil {
+		return err
+	}
+	statements := []string{
+		`DROP TABLE IF EXISTS search_index`,
+		`CREATE VIRTUAL TABLE search_index USING fts5(
+			kind UNINDEXED, ref_id UNINDEXED, title, body, tokenize = 'unicode61 remove_diacritics 2'
+		)`,
+		`DELETE FROM search_index_version`,
+		fmt.Sprintf(`INSERT INTO search_index_version (version) VALUES (%d)`, searchIndexVersion),
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement); err != nil {
+			return err
+		}
+	}
+
+	for _, source := range searchSources {
+		rowID := func(alias string) string {
+			return fmt.Sprintf("%s.id * 4 + %d", alias, source.offset)
+		}
+		values := func(alias string) string {
+			title := strings.ReplaceAll(source.title, "r.", alias+".")
+			body := strings.ReplaceAll(source.body, "r.", alias+".")
+			return fmt.Sprintf("%s, '%s', %s.id, %s, %s", rowID(alias), source.kind, alias, title, body)
+		}
+
+		statements := []string{
+			`INSERT INTO search_index (rowid, kind, ref_id, title, body) SELECT ` + values("r") + ` FROM ` + source.table + ` r;`,
+			`CREATE TRIGGER search_` + source.table + `_insert AFTER INSERT ON ` + source.table + ` BEGIN
+				INSERT INTO search_index (rowid, kind, ref_id, title, body) VALUES (` + values("new") + `);
+			END;`,
+			`CREATE TRIGGER search_` + source.table + `_update AFTER UPDATE OF ` + source.fields + ` ON ` + source.table + ` BEGIN
+				DELETE FROM search_index WHERE rowid = ` + rowID("old") + `;
+				INSERT INTO search_index (rowid, kind, ref_id, title, body) VALUES (` + values("new") + `);
+			END;`,
+			`CREATE TRIGGER search_` + source.table + `_delete AFTER DELETE ON ` + source.table + ` BEGIN
+				DELETE FROM search_index WHERE rowid = ` + rowID("old") + `;
+			END;`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(statement); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
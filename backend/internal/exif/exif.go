@@ -0,0 +1,278 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	markerSOI  = 0xD8
+	markerSOS  = 0xDA
+	markerAPP1 = 0xE1
+
+	tagOrientation  = 0x0112
+	tagGPSInfo      = 0x8825
+	tagGPSLatRef    = 0x0001
+	tagGPSLatitude  = 0x0002
+	tagGPSLonRef    = 0x0003
+	tagGPSLongitude = 0x0004
+
+	typeASCII    = 2
+	typeShort    = 3
+	typeRational = 5
+)
+
+var exifHeader = []byte("Exif\x00\x00")
+
+var errNotJPEG = errors.New("not a JPEG image")
+
+// ErrMalformed is returned by Strip for JPEGs it can't safely clean
+var ErrMalformed = errors.New("malformed JPEG")
+
+// Coordinates is the GPS position recorded by the camera
+type Coordinates struct {
+	Lat float64
+	Lon float64
+}
+
+// ReadGPS extracts the GPS position from a JPEG's EXIF block.
+// It returns nil when the image carries no usable GPS data.
+func ReadGPS(data []byte) *Coordinates {
+	var coords *Coordinates
+	walkSegments(data, func(marker byte, payload []byte) bool {
+		if marker == markerAPP1 && bytes.HasPrefix(payload, exifHeader) {
+			coords = parseTIFF(payload[len(exifHeader):])
+			return false
+		}
+		return true
+	})
+	return coords
+}
+
+// Strip removes EXIF and XMP metadata (APP1 segments) from a JPEG so uploaded
+// photos don't leak location or device details. The EXIF orientation is kept
+// so portrait photos still display upright. Non-JPEG input is returned as is;
+// a JPEG whose segments can't be walked is rejected with ErrMalformed rather
+// than stored with its metadata.
+func Strip(data []byte) ([]byte, error) {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != markerSOI {
+		return data, nil
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+
+	pos := 2
+	keptOrientation := false
+	err := walkSegments(data, func(marker byte, payload []byte) bool {
+		segmentLen := 4 + len(payload)
+		if marker == markerSOS {
+			// Everything from the scan onwards is image data
+			out = append(out, data[pos:]...)
+			pos = len(data)
+			return false
+		}
+		if marker != markerAPP1 {
+			out = append(out, data[pos:pos+segmentLen]...)
+		} else if !keptOrientation && bytes.HasPrefix(payload, exifHeader) {
+			// The orientation goes back where the EXIF block was
+			if orientation := readOrientation(payload[len(exifHeader):]); orientation > 1 {
+				out = append(out, orientationSegment(orientation)...)
+			}
+			keptOrientation = true
+		}
+		pos += segmentLen
+		return true
+	})
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	if pos < len(data) {
+		out = append(out, data[pos:]...)
+	}
+	return out, nil
+}
+
+// readOrientation returns the orientation (1-8) in a TIFF block's first IFD,
+// or 0 when it has none
+func readOrientation(tiff []byte) uint16 {
+	order, ifd0, ok := readTIFFHeader(tiff)
+	if !ok {
+		return 0
+	}
+
+	var orientation uint16
+	forEachEntry(tiff, order, ifd0, func(tag, typ uint16, count, valueOffset uint32, raw []byte) {
+		if tag == tagOrientation && typ == typeShort && count == 1 {
+			orientation = order.Uint16(raw)
+		}
+	})
+	if orientation > 8 {
+		return 0
+	}
+	return orientation
+}
+
+// orientationSegment builds an APP1 EXIF segment holding nothing but the
+// orientation tag
+func orientationSegment(orientation uint16) []byte {
+	tiff := []byte{
+		'M', 'M', 0, 42, 0, 0, 0, 8, // big-endian header, IFD0 at offset 8
+		0, 1, // one entry
+		byte(tagOrientation >> 8), byte(tagOrientation & 0xFF), 0, typeShort, 0, 0, 0, 1,
+		byte(orientation >> 8), byte(orientation), 0, 0,
+		0, 0, 0, 0, // no next IFD
+	}
+	payload := append(append([]byte{}, exifHeader...), tiff...)
+
+	segment := []byte{0xFF, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// walkSegments calls fn for every marker segment up to and including SOS.
+// fn returns false to stop walking.
+func walkSegments(data []byte, fn func(marker byte, payload []byte) bool) error {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != markerSOI {
+		return errNotJPEG
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return errNotJPEG
+		}
+		marker := data[pos+1]
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return errNotJPEG
+		}
+		if !fn(marker, data[pos+4:pos+2+length]) {
+			return nil
+		}
+		pos += 2 + length
+	}
+	return nil
+}
+
+func parseTIFF(tiff []byte) *Coordinates {
+	order, ifd0, ok := readTIFFHeader(tiff)
+	if !ok {
+		return nil
+	}
+
+	gpsOffset, ok := findTag(tiff, order, ifd0, tagGPSInfo)
+	if !ok {
+		return nil
+	}
+
+	var latRef, lonRef string
+	var lat, lon []float64
+	forEachEntry(tiff, order, gpsOffset, func(tag, typ uint16, count, valueOffset uint32, raw []byte) {
+		switch tag {
+		case tagGPSLatRef:
+			latRef = readASCII(typ, raw)
+		case tagGPSLonRef:
+			lonRef = readASCII(typ, raw)
+		case tagGPSLatitude:
+			lat = readRationals(tiff, order, typ, count, valueOffset)
+		case tagGPSLongitude:
+			lon = readRationals(tiff, order, typ, count, valueOffset)
+		}
+	})
+
+	if len(lat) != 3 || len(lon) != 3 {
+		return nil
+	}
+
+	coords := &Coordinates{
+		Lat: lat[0] + lat[1]/60 + lat[2]/3600,
+		Lon: lon[0] + lon[1]/60 + lon[2]/3600,
+	}
+	if latRef == "S" {
+		coords.Lat = -coords.Lat
+	}
+	if lonRef == "W" {
+		coords.Lon = -coords.Lon
+	}
+	if coords.Lat == 0 && coords.Lon == 0 {
+		// Cameras without a fix often write zeroes
+		return nil
+	}
+	return coords
+}
+
+// readTIFFHeader returns a TIFF block's byte order and the offset of its
+// first IFD
+func readTIFFHeader(tiff []byte) (binary.ByteOrder, uint32, bool) {
+	if len(tiff) < 8 {
+		return nil, 0, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, false
+	}
+	return order, order.Uint32(tiff[4:8]), true
+}
+
+func findTag(tiff []byte, order binary.ByteOrder, ifdOffset uint32, want uint16) (uint32, bool) {
+	var value uint32
+	found := false
+	forEachEntry(tiff, order, ifdOffset, func(tag, typ uint16, count, valueOffset uint32, raw []byte) {
+		if tag == want {
+			value = valueOffset
+			found = true
+		}
+	})
+	return value, found
+}
+
+func forEachEntry(tiff []byte, order binary.ByteOrder, ifdOffset uint32, fn func(tag, typ uint16, count, valueOffset uint32, raw []byte)) {
+	if int(ifdOffset)+2 > len(tiff) {
+		return
+	}
+	entries := int(order.Uint16(tiff[ifdOffset:]))
+	base := int(ifdOffset) + 2
+	for i := 0; i < entries; i++ {
+		entry := base + i*12
+		if entry+12 > len(tiff) {
+			return
+		}
+		tag := order.Uint16(tiff[entry:])
+		typ := order.Uint16(tiff[entry+2:])
+		count := order.Uint32(tiff[entry+4:])
+		raw := tiff[entry+8 : entry+12]
+		fn(tag, typ, count, order.Uint32(raw), raw)
+	}
+}
+
+func readASCII(typ uint16, raw []byte) string {
+	if typ != typeASCII {
+		return ""
+	}
+	// Reference values are a single character plus NUL, so they fit inline
+	return string(bytes.TrimRight(raw[:1], "\x00"))
+}
+
+func readRationals(tiff []byte, order binary.ByteOrder, typ uint16, count, offset uint32) []float64 {
+	if typ != typeRational || count != 3 || int(offset)+24 > len(tiff) {
+		return nil
+	}
+	values := make([]float64, 0, 3)
+	for i := uint32(0); i < count; i++ {
+		num := order.Uint32(tiff[offset+i*8:])
+		den := order.Uint32(tiff[offset+i*8+4:])
+		if den == 0 {
+			return nil
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values
+}
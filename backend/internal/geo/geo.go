@@ -0,0 +1,96 @@
+package geo
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+const earthRadiusMeters = 6371000.0
+
+// Point is a WGS84 coordinate in decimal degrees
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Parks holds approximate outlines of the parks challenges are usually tied to.
+// The polygons are deliberately generous so that a photo taken near the gates
+// or in a queue still counts as inside.
+var Parks = map[string][]Point{
+	"magic_kingdom": {
+		{Lat: 28.4222, Lon: -81.5852},
+		{Lat: 28.4222, Lon: -81.5768},
+		{Lat: 28.4150, Lon: -81.5768},
+		{Lat: 28.4150, Lon: -81.5852},
+	},
+	"epcot": {
+		{Lat: 28.3785, Lon: -81.5545},
+		{Lat: 28.3785, Lon: -81.5455},
+		{Lat: 28.3700, Lon: -81.5420},
+		{Lat: 28.3650, Lon: -81.5470},
+		{Lat: 28.3650, Lon: -81.5545},
+	},
+	"hollywood_studios": {
+		{Lat: 28.3615, Lon: -81.5630},
+		{Lat: 28.3615, Lon: -81.5565},
+		{Lat: 28.3535, Lon: -81.5565},
+		{Lat: 28.3535, Lon: -81.5630},
+	},
+	"animal_kingdom": {
+		{Lat: 28.3630, Lon: -81.5965},
+		{Lat: 28.3630, Lon: -81.5845},
+		{Lat: 28.3515, Lon: -81.5845},
+		{Lat: 28.3515, Lon: -81.5965},
+	},
+}
+
+// ParkNames returns the known park identifiers in a stable order
+func ParkNames() []string {
+	names := make([]string, 0, len(Parks))
+	for name := range Parks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NormalizeParkName maps inputs like "Hollywood Studios" to the "hollywood_studios" key
+func NormalizeParkName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// DistanceMeters returns the great-circle distance between two points
+func DistanceMeters(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// InPolygon reports whether p lies inside polygon using ray casting.
+// Park outlines are small enough that treating degrees as planar is fine.
+func InPolygon(p Point, polygon []Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lon < (b.Lon-a.Lon)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// InPark reports whether p lies inside the named park. Unknown parks never match.
+func InPark(p Point, park string) bool {
+	polygon, ok := Parks[NormalizeParkName(park)]
+	if !ok {
+		return false
+	}
+	return InPolygon(p, polygon)
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strconv"
+	"time"
+)
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	execer
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// auditSnapshot is the state of an audit target before or after a change
+type auditSnapshot struct {
+	Challenge  *models.Challenge           `json:"challenge,omitempty"`
+	Placements []models.ChallengePlacement `json:"placements,omitempty"`
+	Post       *auditPost                  `json:"post,omitempty"`
+}
+
+// auditPost holds the post fields that decide whether it earns points
+type auditPost struct {
+	ID               int        `json:"id"`
+	UserID           int        `json:"user_id"`
+	ChallengeID      int        `json:"challenge_id"`
+	Revoked          bool       `json:"revoked"`
+	RevokeReason     *string    `json:"revoke_reason,omitempty"`
+	ModerationStatus *string    `json:"moderation_status,omitempty"`
+	RemovedAt        *time.Time `json:"removed_at,omitempty"`
+}
+
+// auditState snapshots a challenge with its placements and/or a post; pass 0
+// to leave either out. It returns nil when neither exists.
+func auditState(db querier, challengeID, postID int) (*auditSnapshot, error) {
+	snapshot := &auditSnapshot{}
+
+	if postID != 0 {
+		var post auditPost
+		err := db.QueryRow(`
+			SELECT id, user_id, challenge_id, revoked, revoke_reason, moderation_status, removed_at FROM posts WHERE id = ?
+		`, postID).Scan(&post.ID, &post.UserID, &post.ChallengeID, &post.Revoked, &post.RevokeReason, &post.ModerationStatus,
+			&post.RemovedAt)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			snapshot.Post = &post
+		}
+	}
+
+	if challengeID != 0 {
+		var challenge models.Challenge
+		err := db.QueryRow(`
+			SELECT `+challengeColumns+`
+			FROM challenges c WHERE c.id = ?
+		`, challengeID).Scan(challengeScanFields(&challenge)...)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if err == nil {
+			snapshot.Challenge = &challenge
+		}
+
+		rows, err := db.Query(`
+			SELECT challenge_id, user_id, post_id, place, points, created_at FROM challenge_placements
+			WHERE challenge_id = ? ORDER BY place IS NULL, place, user_id
+		`, challengeID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var placement models.ChallengePlacement
+			err := rows.Scan(&placement.ChallengeID, &placement.UserID, &placement.PostID, &placement.Place,
+				&placement.Points, &placement.CreatedAt)
+			if err != nil {
+				return nil, err
+			}
+			snapshot.Placements = append(snapshot.Placements, placement)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if snapshot.Challenge == nil && snapshot.Post == nil {
+		return nil, nil
+	}
+	return snapshot, nil
+}
+
+// recordAudit stores an audit event. r is the admin or player request that
+// made the change, or nil when the scheduler made it.
+func recordAudit(db execer, r *http.Request, action, targetType string, targetID int, before, after *auditSnapshot) error {
+	var actorID *int
+	var ip, requestID *string
+	if r != nil {
+		if user, ok := r.Context().Value(middleware.UserContextKey).(models.User); ok {
+			actorID = &user.ID
+		}
+		ip = nullIfEmpty(middleware.ClientIP(r))
+		requestID = nullIfEmpty(middleware.RequestID(r))
+	}
+
+	beforeJSON, err := snapshotJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := snapshotJSON(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO audit_events (actor_id, action, target_type, target_id, before_state, after_state, ip, request_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, actorID, action, targetType, targetID, beforeJSON, afterJSON, ip, requestID)
+	return err
+}
+
+func snapshotJSON(snapshot *auditSnapshot) (*string, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+	text := string(data)
+	return &text, nil
+}
+
+// GetAuditLog pages through audit events, newest first. Filters: actor_id,
+// action, target_type, target_id, since and until (RFC3339); page and limit
+// work like the feed.
+func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page := 1
+	limit := 50
+	if p := query.Get("page"); p != "" {
+		if pageNum, err := strconv.Atoi(p); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+	if l := query.Get("limit"); l != "" {
+		if limitNum, err := strconv.Atoi(l); err == nil && limitNum > 0 && limitNum <= 200 {
+			limit = limitNum
+		}
+	}
+
+	var filters string
+	var args []interface{}
+	for _, filter := range []struct{ param, column string }{
+		{"actor_id", "a.actor_id"},
+		{"target_id", "a.target_id"},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid "+filter.param, http.StatusBadRequest)
+				return
+			}
+			filters += ` AND ` + filter.column + ` = ?`
+			args = append(args, id)
+		}
+	}
+	for _, filter := range []struct{ param, column string }{
+		{"action", "a.action"},
+		{"target_type", "a.target_type"},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			filters += ` AND ` + filter.column + ` = ?`
+			args = append(args, value)
+		}
+	}
+	for _, filter := range []struct{ param, op string }{
+		{"since", ">="},
+		{"until", "<"},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			at, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				http.Error(w, "Invalid "+filter.param+" format. Use RFC3339 format", http.StatusBadRequest)
+				return
+			}
+			filters += ` AND a.created_at ` + filter.op + ` ?`
+			args = append(args, at.UTC().Format("2006-01-02 15:04:05"))
+		}
+	}
+
+	auditLog := models.AuditLog{Events: []models.AuditEvent{}, Page: page, Limit: limit}
+	err := h.db.QueryRow(`SELECT COUNT(*) FROM audit_events a WHERE 1 = 1`+filters, args...).Scan(&auditLog.Total)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT a.id, a.actor_id, u.username, a.action, a.target_type, a.target_id,
+			a.before_state, a.after_state, a.ip, a.request_id, a.created_at
+		FROM audit_events a
+		LEFT JOIN users u ON a.actor_id = u.id
+		WHERE 1 = 1`+filters+`
+		ORDER BY a.created_at DESC, a.id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event models.AuditEvent
+		var before, after *string
+		err := rows.Scan(&event.ID, &event.ActorID, &event.ActorUsername, &event.Action, &event.TargetType, &event.TargetID,
+			&before, &after, &event.IP, &event.RequestID, &event.CreatedAt)
+		if err != nil {
+			http.Error(w, "Failed to scan audit event", http.StatusInternalServerError)
+			return
+		}
+		if before != nil {
+			event.Before = json.RawMessage(*before)
+		}
+		if after != nil {
+			event.After = json.RawMessage(*after)
+		}
+		auditLog.Events = append(auditLog.Events, event)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(auditLog)
+}
@@ -3,12 +3,15 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"orlando-app/internal/exif"
 	"orlando-app/internal/middleware"
 	"orlando-app/internal/models"
+	"orlando-app/internal/tags"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -31,12 +34,13 @@ func (h *Handler) PickChallenge(w http.ResponseWriter, r *http.Request) {
 	// Get challenge information
 	var challengeType string
 	var challengeStatus string
+	var minPoints *int
 	err = h.db.QueryRow(`
-		SELECT challenge_type, status FROM challenges 
-		WHERE id = ?
-		AND (start_date IS NULL OR start_date <= CURRENT_TIMESTAMP)
-		AND (end_date IS NULL OR end_date >= CURRENT_TIMESTAMP)
-	`, challengeID).Scan(&challengeType, &challengeStatus)
+		SELECT c.challenge_type, c.status, c.min_points FROM challenges c
+		WHERE c.id = ?
+		AND `+activeWindowSQL+`
+		AND `+unlockedSQL+`
+	`, challengeID, user.ID).Scan(&challengeType, &challengeStatus, &minPoints)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Challenge not found or outside date range", http.StatusNotFound)
@@ -51,10 +55,36 @@ func (h *Handler) PickChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	prerequisiteIDs, err := h.challengePrerequisites(challengeID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	progress, err := h.loadProgress(user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	lock := progress.lockFor(models.Challenge{
+		ID: challengeID, ChallengeType: challengeType, Status: challengeStatus,
+		PrerequisiteIDs: prerequisiteIDs, MinPoints: minPoints,
+	})
+	if lock != nil {
+		http.Error(w, lock.reason(), http.StatusForbidden)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
 	if challengeType == "open" {
 		// For open challenges, check if user already has a submission
 		var existingSubmission int
-		err = h.db.QueryRow(`
+		err = tx.QueryRow(`
 			SELECT COUNT(*) FROM challenge_submissions 
 			WHERE challenge_id = ? AND user_id = ?
 		`, challengeID, user.ID).Scan(&existingSubmission)
@@ -69,7 +99,7 @@ func (h *Handler) PickChallenge(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Add user to challenge submissions (without post_id initially)
-		_, err = h.db.Exec(`
+		_, err = tx.Exec(`
 			INSERT INTO challenge_submissions (challenge_id, user_id, post_id)
 			VALUES (?, ?, 0)
 		`, challengeID, user.ID)
@@ -77,13 +107,11 @@ func (h *Handler) PickChallenge(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Failed to join challenge", http.StatusInternalServerError)
 			return
 		}
-
-		log.Printf("User %d successfully joined open challenge %d", user.ID, challengeID)
 	} else {
 		// For exclusive challenges, use the original logic
 		// Check if user already has this specific challenge
 		var alreadyAssigned int
-		err = h.db.QueryRow(`
+		err = tx.QueryRow(`
 			SELECT COUNT(*) FROM challenges WHERE id = ? AND assigned_to = ?
 		`, challengeID, user.ID).Scan(&alreadyAssigned)
 		if err != nil {
@@ -97,13 +125,16 @@ func (h *Handler) PickChallenge(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Try to assign the challenge
-		result, err := h.db.Exec(`
-			UPDATE challenges 
-			SET assigned_to = ?, status = 'in_progress'
-			WHERE id = ? AND assigned_to IS NULL AND status = 'available'
-			AND (start_date IS NULL OR start_date <= CURRENT_TIMESTAMP)
-			AND (end_date IS NULL OR end_date >= CURRENT_TIMESTAMP)
-		`, user.ID, challengeID)
+		// Claims expire after the challenge's own TTL, falling back to the global default
+		result, err := tx.Exec(`
+			UPDATE challenges AS c
+			SET assigned_to = ?, status = 'in_progress', claim_extended = FALSE,
+				claim_expires_at = CASE WHEN COALESCE(c.claim_ttl_minutes, ?) > 0
+					THEN datetime(CURRENT_TIMESTAMP, '+' || COALESCE(c.claim_ttl_minutes, ?) || ' minutes')
+				END
+			WHERE c.id = ? AND c.assigned_to IS NULL AND c.status = 'available'
+			AND `+activeWindowSQL+`
+		`, user.ID, h.cfg.ClaimTTLMinutes, h.cfg.ClaimTTLMinutes, challengeID)
 
 		if err != nil {
 			http.Error(w, "Database error", http.StatusInternalServerError)
@@ -120,7 +151,44 @@ func (h *Handler) PickChallenge(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "Challenge not available (may be assigned to another user)", http.StatusConflict)
 			return
 		}
+	}
+
+	// The claim is written before counting so two concurrent picks can't both
+	// slip under the limit; going over it rolls this pick back
+	if h.cfg.MaxHeldChallenges > 0 {
+		held, err := heldChallenges(tx, user.ID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
 
+		if len(held) > h.cfg.MaxHeldChallenges {
+			alreadyHeld := []models.HeldChallenge{}
+			for _, c := range held {
+				if c.ID != challengeID {
+					alreadyHeld = append(alreadyHeld, c)
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error":           fmt.Sprintf("You can only hold %d challenges at a time. Complete or cancel one before picking another.", h.cfg.MaxHeldChallenges),
+				"limit":           h.cfg.MaxHeldChallenges,
+				"held_challenges": alreadyHeld,
+			})
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	if challengeType == "open" {
+		log.Printf("User %d successfully joined open challenge %d", user.ID, challengeID)
+	} else {
 		log.Printf("Challenge %d successfully picked by user %d", challengeID, user.ID)
 	}
 
@@ -173,7 +241,7 @@ func (h *Handler) CancelChallenge(w http.ResponseWriter, r *http.Request) {
 		// For exclusive challenges, use original logic
 		result, err := h.db.Exec(`
 			UPDATE challenges 
-			SET assigned_to = NULL, status = 'available'
+			SET assigned_to = NULL, status = 'available', claim_expires_at = NULL
 			WHERE id = ? AND assigned_to = ?
 		`, challengeID, user.ID)
 
@@ -199,6 +267,59 @@ func (h *Handler) CancelChallenge(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Challenge cancelled successfully"})
 }
 
+// ExtendClaim lets the holder of an exclusive challenge push its claim
+// expiry back once
+func (h *Handler) ExtendClaim(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	vars := mux.Vars(r)
+	challengeID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+		return
+	}
+
+	if !h.cfg.AllowClaimExtension {
+		http.Error(w, "Claim extensions are disabled", http.StatusForbidden)
+		return
+	}
+
+	result, err := h.db.Exec(`
+		UPDATE challenges
+		SET claim_expires_at = datetime(claim_expires_at, ?), claim_extended = TRUE
+		WHERE id = ? AND assigned_to = ? AND status = 'in_progress'
+		AND claim_expires_at IS NOT NULL AND COALESCE(claim_extended, FALSE) = FALSE
+	`, fmt.Sprintf("+%d minutes", h.cfg.ClaimExtensionMinutes), challengeID, user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if rowsAffected == 0 {
+		http.Error(w, "Challenge not assigned to you, has no claim timeout, or was already extended", http.StatusConflict)
+		return
+	}
+
+	var claimExpiresAt time.Time
+	err = h.db.QueryRow(`SELECT claim_expires_at FROM challenges WHERE id = ?`, challengeID).Scan(&claimExpiresAt)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Claim on challenge %d extended by user %d until %s", challengeID, user.ID, claimExpiresAt.Format(time.RFC3339))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":          "Claim extended successfully",
+		"claim_expires_at": claimExpiresAt,
+	})
+}
+
 // UploadMedia handles background media upload
 func (h *Handler) UploadMedia(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(models.User)
@@ -244,29 +365,32 @@ func (h *Handler) UploadMedia(w http.ResponseWriter, r *http.Request) {
 	}
 	mediaID := hex.EncodeToString(randomBytes)
 
-	// Save file with unique name
+	// Save file with unique name, keeping any GPS position for geofence checks
 	filename := fmt.Sprintf("%s_%d_%s", mediaID, user.ID, header.Filename)
 	filepath := filepath.Join("./uploads/temp", filename)
 
-	dst, err := os.Create(filepath)
+	coords, err := saveMedia(filepath, file, mediaType)
 	if err != nil {
-		http.Error(w, "Failed to create file", http.StatusInternalServerError)
+		if errors.Is(err, exif.ErrMalformed) {
+			http.Error(w, "Photo could not be read; please upload a valid JPEG", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
 	}
-	defer dst.Close()
 
-	if _, err := io.Copy(dst, file); err != nil {
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
-		return
+	var gpsLat, gpsLon *float64
+	if coords != nil {
+		gpsLat, gpsLon = &coords.Lat, &coords.Lon
 	}
 
 	mediaURL := fmt.Sprintf("/uploads/temp/%s", filename)
 
 	// Store temporary media info in database
 	_, err = h.db.Exec(`
-		INSERT INTO temp_media (media_id, user_id, media_url, media_type, created_at, expires_at)
-		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP, datetime(CURRENT_TIMESTAMP, '+1 hour'))
-	`, mediaID, user.ID, mediaURL, mediaType)
+		INSERT INTO temp_media (media_id, user_id, media_url, media_type, gps_lat, gps_lon, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, datetime(CURRENT_TIMESTAMP, '+1 hour'))
+	`, mediaID, user.ID, mediaURL, mediaType, gpsLat, gpsLon)
 
 	if err != nil {
 		log.Printf("Failed to store temp media: %v", err)
@@ -292,7 +416,8 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var mediaURL, mediaType, caption string
+	var mediaURL, mediaType, caption, storedPath string
+	var coords *exif.Coordinates
 
 	// Check if this is a JSON request (pre-uploaded media) or form data (direct upload)
 	contentType := r.Header.Get("Content-Type")
@@ -316,10 +441,11 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 
 		// Get temp media info
 		var tempMediaURL string
+		var gpsLat, gpsLon sql.NullFloat64
 		err = h.db.QueryRow(`
-			SELECT media_url, media_type FROM temp_media 
+			SELECT media_url, media_type, gps_lat, gps_lon FROM temp_media 
 			WHERE media_id = ? AND user_id = ? AND expires_at > CURRENT_TIMESTAMP
-		`, req.MediaID, user.ID).Scan(&tempMediaURL, &mediaType)
+		`, req.MediaID, user.ID).Scan(&tempMediaURL, &mediaType, &gpsLat, &gpsLon)
 
 		if err != nil {
 			if err == sql.ErrNoRows {
@@ -346,7 +472,11 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 		}
 
 		mediaURL = fmt.Sprintf("/uploads/posts/%s", finalFilename)
+		storedPath = finalPath
 		caption = req.Caption
+		if gpsLat.Valid && gpsLon.Valid {
+			coords = &exif.Coordinates{Lat: gpsLat.Float64, Lon: gpsLon.Float64}
+		}
 
 		// Clean up temp media record
 		h.db.Exec("DELETE FROM temp_media WHERE media_id = ?", req.MediaID)
@@ -390,19 +520,18 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 		filename := fmt.Sprintf("%d_%d_%s", user.ID, challengeID, header.Filename)
 		filepath := filepath.Join("./uploads/posts", filename)
 
-		dst, err := os.Create(filepath)
+		coords, err = saveMedia(filepath, file, mediaType)
 		if err != nil {
-			http.Error(w, "Failed to create file", http.StatusInternalServerError)
-			return
-		}
-		defer dst.Close()
-
-		if _, err := io.Copy(dst, file); err != nil {
+			if errors.Is(err, exif.ErrMalformed) {
+				http.Error(w, "Photo could not be read; please upload a valid JPEG", http.StatusBadRequest)
+				return
+			}
 			http.Error(w, "Failed to save file", http.StatusInternalServerError)
 			return
 		}
 
 		mediaURL = fmt.Sprintf("/uploads/posts/%s", filename)
+		storedPath = filepath
 	}
 
 	// Start transaction
@@ -414,13 +543,11 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback()
 
 	// Get challenge information
-	var challengePoints int
-	var challengeType string
-	var challengeStatus string
+	var challenge models.Challenge
 	err = tx.QueryRow(`
-		SELECT points, challenge_type, status FROM challenges 
-		WHERE id = ?
-	`, challengeID).Scan(&challengePoints, &challengeType, &challengeStatus)
+		SELECT `+challengeColumns+`
+		FROM challenges c WHERE c.id = ?
+	`, challengeID).Scan(challengeScanFields(&challenge)...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -431,6 +558,21 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	challengePoints := challenge.Points
+	challengeType := challenge.ChallengeType
+
+	before, err := auditState(tx, challengeID, 0)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Held challenges can still be completed during the grace period after end_date
+	if err := h.checkWindow(challenge, phaseComplete, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
 	// Verify user can complete this challenge
 	if challengeType == "exclusive" {
 		// For exclusive challenges, verify it's assigned to the user (use original simple logic)
@@ -479,24 +621,51 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Create post
+	// Create post, recording whether the photo was taken inside the challenge geofence
 	var postID int
 	err = tx.QueryRow(`
-		INSERT INTO posts (user_id, challenge_id, media_url, media_type, caption)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO posts (user_id, challenge_id, media_url, media_type, caption, location_status, media_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 		RETURNING id
-	`, user.ID, challengeID, mediaURL, mediaType, caption).Scan(&postID)
+	`, user.ID, challengeID, mediaURL, mediaType, caption, locationStatus(challenge, coords), mediaHash(storedPath)).Scan(&postID)
 
 	if err != nil {
 		http.Error(w, "Failed to create post", http.StatusInternalServerError)
 		return
 	}
 
-	if challengeType == "exclusive" {
+	message := fmt.Sprintf("%s mentioned you in their post for \"%s\"", user.Username, challenge.Title)
+	if err := setMentions(tx, user, postID, nil, caption, message); err != nil {
+		http.Error(w, "Failed to save mentions", http.StatusInternalServerError)
+		return
+	}
+	if err := setHashtags(tx, postID, nil, caption); err != nil {
+		http.Error(w, "Failed to save hashtags", http.StatusInternalServerError)
+		return
+	}
+	if err := h.scoreNewPost(tx, postID, challengePoints); err != nil {
+		http.Error(w, "Failed to score post", http.StatusInternalServerError)
+		return
+	}
+
+	if challengeType == "exclusive" && challenge.RequiresVerification {
+		// Points only count once other players or a moderator verify the completion
+		_, err = tx.Exec(`
+			UPDATE challenges 
+			SET assigned_to = NULL, status = 'pending_verification', completed_by = ?, completed_post_id = ?, claim_expires_at = NULL,
+				verification_due_at = datetime(CURRENT_TIMESTAMP, ?), verification_escalated = FALSE
+			WHERE id = ?
+		`, user.ID, postID, h.verificationDueSQL(), challengeID)
+
+		if err != nil {
+			http.Error(w, "Failed to update challenge", http.StatusInternalServerError)
+			return
+		}
+	} else if challengeType == "exclusive" {
 		// For exclusive challenges, mark as completed and award points immediately
 		_, err = tx.Exec(`
 			UPDATE challenges 
-			SET assigned_to = NULL, status = 'completed', completed_by = ?, completed_post_id = ?, completed_at = CURRENT_TIMESTAMP
+			SET assigned_to = NULL, status = 'completed', completed_by = ?, completed_post_id = ?, completed_at = CURRENT_TIMESTAMP, claim_expires_at = NULL
 			WHERE id = ?
 		`, user.ID, postID, challengeID)
 
@@ -520,13 +689,32 @@ func (h *Handler) CompleteChallenge(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Exclusive completions credit (or queue for verification) points straight away
+	if challengeType == "exclusive" {
+		after, err := auditState(tx, challengeID, postID)
+		if err == nil {
+			err = recordAudit(tx, r, "challenge_complete", "challenge", challengeID, before, after)
+		}
+		if err != nil {
+			http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	if err = tx.Commit(); err != nil {
 		http.Error(w, "Failed to complete transaction", http.StatusInternalServerError)
 		return
 	}
 
 	var response map[string]interface{}
-	if challengeType == "exclusive" {
+	if challengeType == "exclusive" && challenge.RequiresVerification {
+		response = map[string]interface{}{
+			"message":       "Challenge completed. Awaiting verification.",
+			"post_id":       postID,
+			"points_earned": 0,
+			"status":        "pending_verification",
+		}
+	} else if challengeType == "exclusive" {
 		response = map[string]interface{}{
 			"message":       "Challenge completed successfully",
 			"post_id":       postID,
@@ -582,6 +770,52 @@ func (h *Handler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var geofence models.CreateChallengeRequest
+	if err := geofenceFromForm(r, &geofence); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateGeofence(&geofence); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var claimTTL *int
+	if ttlStr := r.FormValue("claim_ttl_minutes"); ttlStr != "" {
+		ttl, err := strconv.Atoi(ttlStr)
+		if err != nil || ttl < 0 {
+			http.Error(w, "Invalid claim_ttl_minutes value", http.StatusBadRequest)
+			return
+		}
+		claimTTL = &ttl
+	}
+
+	category := r.FormValue("category")
+	challengeTags, err := tagsFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var unlock models.CreateChallengeRequest
+	if err := unlockRulesFromForm(r, &unlock); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := secretFromForm(r, &unlock); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := placementsFromForm(r, &unlock); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	prerequisiteIDs, err := h.validatePrerequisites(0, unlock.PrerequisiteIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	var startDate, endDate *time.Time
 	if startDateStr != "" {
 		parsed, err := time.Parse(time.RFC3339, startDateStr)
@@ -601,6 +835,22 @@ func (h *Handler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
 		endDate = &parsed
 	}
 
+	unlock.ChallengeType = challengeType
+	unlock.EndDate = endDate
+	if err := votingFromForm(r, &unlock); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	requiresVerification := false
+	if value := r.FormValue("requires_verification"); value != "" {
+		requiresVerification, err = strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid requires_verification value", http.StatusBadRequest)
+			return
+		}
+	}
+
 	var imageURL *string
 
 	// Handle file upload if present
@@ -633,38 +883,95 @@ func (h *Handler) CreateChallenge(w http.ResponseWriter, r *http.Request) {
 		imageURL = &url
 	}
 
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
 	var challengeID int
-	err = h.db.QueryRow(`
-		INSERT INTO challenges (title, description, image_url, points, start_date, end_date, challenge_type)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+	err = tx.QueryRow(`
+		INSERT INTO challenges (title, description, image_url, points, start_date, end_date, challenge_type,
+			geofence_lat, geofence_lon, geofence_radius_m, geofence_park, claim_ttl_minutes, category,
+			min_points, show_when_locked, hidden, unlock_code, unlock_scope, placement_points, participation_points,
+			voting_ends_at, requires_verification)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING id
-	`, title, description, imageURL, points, startDate, endDate, challengeType).Scan(&challengeID)
+	`, title, description, imageURL, points, startDate, endDate, challengeType,
+		geofence.GeofenceLat, geofence.GeofenceLon, geofence.GeofenceRadius, geofence.GeofencePark, claimTTL,
+		normalizeCategory(&category), unlock.MinPoints, unlock.ShowWhenLocked,
+		unlock.Hidden, unlock.UnlockCode, nullIfEmpty(unlock.UnlockScope),
+		models.PointsList(unlock.PlacementPoints), unlock.ParticipationPoints, unlock.VotingEndsAt,
+		requiresVerification).Scan(&challengeID)
 
 	if err != nil {
 		http.Error(w, "Failed to create challenge", http.StatusInternalServerError)
 		return
 	}
 
+	if err := setChallengeTags(tx, challengeID, challengeTags); err != nil {
+		http.Error(w, "Failed to save challenge tags", http.StatusInternalServerError)
+		return
+	}
+
+	if err := setChallengePrerequisites(tx, challengeID, prerequisiteIDs); err != nil {
+		http.Error(w, "Failed to save challenge prerequisites", http.StatusInternalServerError)
+		return
+	}
+
+	after, err := auditState(tx, challengeID, 0)
+	if err == nil {
+		err = recordAudit(tx, r, "challenge_create", "challenge", challengeID, nil, after)
+	}
+	if err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
 	var challenge models.Challenge
 	err = h.db.QueryRow(`
-		SELECT id, title, description, image_url, points, assigned_to, status, start_date, end_date, challenge_type, created_at
-		FROM challenges WHERE id = ?
-	`, challengeID).Scan(
-		&challenge.ID, &challenge.Title, &challenge.Description,
-		&challenge.ImageURL, &challenge.Points, &challenge.AssignedTo,
-		&challenge.Status, &challenge.StartDate, &challenge.EndDate, &challenge.ChallengeType, &challenge.CreatedAt,
-	)
+		SELECT `+challengeColumns+`
+		FROM challenges c WHERE c.id = ?
+	`, challengeID).Scan(challengeScanFields(&challenge)...)
 
 	if err != nil {
 		http.Error(w, "Failed to fetch created challenge", http.StatusInternalServerError)
 		return
 	}
+	challenge.Tags = challengeTags
+	challenge.PrerequisiteIDs = prerequisiteIDs
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(challenge)
 }
 
+// loadChallengeRequest fills req with a challenge's current settings. Tags
+// and prerequisites are left nil.
+func (h *Handler) loadChallengeRequest(challengeID int, req *models.CreateChallengeRequest) error {
+	var placementPoints models.PointsList
+	err := h.db.QueryRow(`
+		SELECT title, description, points, start_date, end_date, challenge_type,
+			geofence_lat, geofence_lon, geofence_radius_m, geofence_park, claim_ttl_minutes,
+			category, min_points, COALESCE(show_when_locked, FALSE),
+			COALESCE(hidden, FALSE), unlock_code, COALESCE(unlock_scope, ''),
+			placement_points, COALESCE(participation_points, 0), voting_ends_at, COALESCE(requires_verification, FALSE)
+		FROM challenges WHERE id = ?
+	`, challengeID).Scan(&req.Title, &req.Description, &req.Points, &req.StartDate, &req.EndDate, &req.ChallengeType,
+		&req.GeofenceLat, &req.GeofenceLon, &req.GeofenceRadius, &req.GeofencePark, &req.ClaimTTLMinutes,
+		&req.Category, &req.MinPoints, &req.ShowWhenLocked,
+		&req.Hidden, &req.UnlockCode, &req.UnlockScope,
+		&placementPoints, &req.ParticipationPoints, &req.VotingEndsAt, &req.RequiresVerification)
+	req.PlacementPoints = placementPoints
+	return err
+}
+
 func (h *Handler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	challengeID, err := strconv.Atoi(vars["id"])
@@ -673,38 +980,160 @@ func (h *Handler) UpdateChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The body is decoded over the current settings so fields it leaves out
+	// keep their values, like tags and prerequisites below
 	var req models.CreateChallengeRequest
+	if err := h.loadChallengeRequest(challengeID, &req); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Challenge not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	_, err = h.db.Exec(`
+	if err := validateGeofence(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if req.ClaimTTLMinutes != nil && *req.ClaimTTLMinutes < 0 {
+		http.Error(w, "Invalid claim_ttl_minutes value", http.StatusBadRequest)
+		return
+	}
+
+	// Tags are only replaced when the request includes them
+	var challengeTags []string
+	if req.Tags != nil {
+		challengeTags, err = tags.NormalizeAll(req.Tags)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := validateMinPoints(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateSecret(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validatePlacements(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validateVoting(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Like tags, prerequisites are only replaced when the request includes them
+	var prerequisiteIDs []int
+	if req.PrerequisiteIDs != nil {
+		prerequisiteIDs, err = h.validatePrerequisites(challengeID, req.PrerequisiteIDs)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Failed to start transaction", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := auditState(tx, challengeID, 0)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if before == nil {
+		http.Error(w, "Challenge not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = tx.Exec(`
 		UPDATE challenges 
-		SET title = ?, description = ?, points = ?, start_date = ?, end_date = ?, challenge_type = ?
+		SET title = ?, description = ?, points = ?, start_date = ?, end_date = ?, challenge_type = ?,
+			geofence_lat = ?, geofence_lon = ?, geofence_radius_m = ?, geofence_park = ?, claim_ttl_minutes = ?,
+			category = ?, min_points = ?, show_when_locked = ?,
+			hidden = ?, unlock_code = ?, unlock_scope = ?,
+			placement_points = ?, participation_points = ?, voting_ends_at = ?, requires_verification = ?
 		WHERE id = ?
-	`, req.Title, req.Description, req.Points, req.StartDate, req.EndDate, req.ChallengeType, challengeID)
+	`, req.Title, req.Description, req.Points, req.StartDate, req.EndDate, req.ChallengeType,
+		req.GeofenceLat, req.GeofenceLon, req.GeofenceRadius, req.GeofencePark, req.ClaimTTLMinutes,
+		normalizeCategory(req.Category), req.MinPoints, req.ShowWhenLocked,
+		req.Hidden, req.UnlockCode, nullIfEmpty(req.UnlockScope),
+		models.PointsList(req.PlacementPoints), req.ParticipationPoints, req.VotingEndsAt,
+		req.RequiresVerification, challengeID)
 
 	if err != nil {
 		http.Error(w, "Failed to update challenge", http.StatusInternalServerError)
 		return
 	}
 
+	if req.Tags != nil {
+		if err := setChallengeTags(tx, challengeID, challengeTags); err != nil {
+			http.Error(w, "Failed to save challenge tags", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if req.PrerequisiteIDs != nil {
+		if err := setChallengePrerequisites(tx, challengeID, prerequisiteIDs); err != nil {
+			http.Error(w, "Failed to save challenge prerequisites", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	after, err := auditState(tx, challengeID, 0)
+	if err == nil {
+		err = recordAudit(tx, r, "challenge_update", "challenge", challengeID, before, after)
+	}
+	if err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
 	var challenge models.Challenge
 	err = h.db.QueryRow(`
-		SELECT id, title, description, image_url, points, assigned_to, status, start_date, end_date, challenge_type, created_at
-		FROM challenges WHERE id = ?
-	`, challengeID).Scan(
-		&challenge.ID, &challenge.Title, &challenge.Description,
-		&challenge.ImageURL, &challenge.Points, &challenge.AssignedTo,
-		&challenge.Status, &challenge.StartDate, &challenge.EndDate, &challenge.ChallengeType, &challenge.CreatedAt,
-	)
+		SELECT `+challengeColumns+`
+		FROM challenges c WHERE c.id = ?
+	`, challengeID).Scan(challengeScanFields(&challenge)...)
 
 	if err != nil {
 		http.Error(w, "Failed to fetch updated challenge", http.StatusInternalServerError)
 		return
 	}
 
+	challenge.Tags, err = h.challengeTags(challengeID)
+	if err != nil {
+		http.Error(w, "Failed to load challenge tags", http.StatusInternalServerError)
+		return
+	}
+
+	challenge.PrerequisiteIDs, err = h.challengePrerequisites(challengeID)
+	if err != nil {
+		http.Error(w, "Failed to load challenge prerequisites", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(challenge)
 }
@@ -717,7 +1146,36 @@ func (h *Handler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.db.Exec(`DELETE FROM challenges WHERE id = ?`, challengeID)
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := auditState(tx, challengeID, 0)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Foreign keys aren't enforced, so the challenge's rows go with it in
+	// one transaction rather than leaving a half-deleted challenge behind
+	for _, query := range []string{
+		`DELETE FROM challenge_tags WHERE challenge_id = ?`,
+		`DELETE FROM challenge_prerequisites WHERE ? IN (challenge_id, required_challenge_id)`,
+		`DELETE FROM challenge_unlocks WHERE challenge_id = ?`,
+		`DELETE FROM challenge_placements WHERE challenge_id = ?`,
+		`DELETE FROM challenge_votes WHERE challenge_id = ?`,
+		`DELETE FROM completion_reviews WHERE challenge_id = ?`,
+	} {
+		if _, err := tx.Exec(query, challengeID); err != nil {
+			http.Error(w, "Failed to delete challenge", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM challenges WHERE id = ?`, challengeID)
 	if err != nil {
 		http.Error(w, "Failed to delete challenge", http.StatusInternalServerError)
 		return
@@ -734,21 +1192,36 @@ func (h *Handler) DeleteChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := recordAudit(tx, r, "challenge_delete", "challenge", challengeID, before, nil); err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
 func (h *Handler) GetAllChallenges(w http.ResponseWriter, r *http.Request) {
+	filterSQL, filterArgs := challengeFilterSQL(r)
+
 	rows, err := h.db.Query(`
-		SELECT 
-			c.id, c.title, c.description, c.image_url, c.points, c.assigned_to, c.status, 
-			c.completed_by, c.completed_post_id, c.completed_at, c.start_date, c.end_date, c.challenge_type, c.created_at,
+		SELECT `+challengeColumns+`,
 			u_completed.username as completed_by_username,
-			u_assigned.username as assigned_to_username
+			u_assigned.username as assigned_to_username,
+			cp.location_status as completed_location_status,
+			c.unlock_code,
+			(SELECT COUNT(*) FROM challenge_unlocks cu WHERE cu.challenge_id = c.id) as unlock_count
 		FROM challenges c
 		LEFT JOIN users u_completed ON c.completed_by = u_completed.id
 		LEFT JOIN users u_assigned ON c.assigned_to = u_assigned.id
+		LEFT JOIN posts cp ON c.completed_post_id = cp.id
+		WHERE 1 = 1 `+filterSQL+`
 		ORDER BY c.created_at DESC
-	`)
+	`, filterArgs...)
 
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
@@ -759,12 +1232,10 @@ func (h *Handler) GetAllChallenges(w http.ResponseWriter, r *http.Request) {
 	var challenges []models.Challenge
 	for rows.Next() {
 		var challenge models.Challenge
-		err := rows.Scan(
-			&challenge.ID, &challenge.Title, &challenge.Description,
-			&challenge.ImageURL, &challenge.Points, &challenge.AssignedTo,
-			&challenge.Status, &challenge.CompletedBy, &challenge.CompletedPostID,
-			&challenge.CompletedAt, &challenge.StartDate, &challenge.EndDate, &challenge.ChallengeType, &challenge.CreatedAt, &challenge.CompletedByUsername, &challenge.AssignedToUsername,
-		)
+		err := rows.Scan(append(challengeScanFields(&challenge),
+			&challenge.CompletedByUsername, &challenge.AssignedToUsername, &challenge.CompletedLocationStatus,
+			&challenge.UnlockCode, &challenge.UnlockCount,
+		)...)
 		if err != nil {
 			http.Error(w, "Failed to scan challenge", http.StatusInternalServerError)
 			return
@@ -775,9 +1246,11 @@ func (h *Handler) GetAllChallenges(w http.ResponseWriter, r *http.Request) {
 			submissionRows, err := h.db.Query(`
 				SELECT 
 					cs.id, cs.user_id, cs.post_id, cs.created_at,
-					u.username, u.profile_image
+					u.username, u.profile_image, p.location_status, cpl.place, cpl.points
 				FROM challenge_submissions cs
 				JOIN users u ON cs.user_id = u.id
+				LEFT JOIN posts p ON cs.post_id = p.id
+				LEFT JOIN challenge_placements cpl ON cpl.challenge_id = cs.challenge_id AND cpl.user_id = cs.user_id
 				WHERE cs.challenge_id = ?
 				ORDER BY cs.created_at DESC
 			`, challenge.ID)
@@ -790,7 +1263,8 @@ func (h *Handler) GetAllChallenges(w http.ResponseWriter, r *http.Request) {
 					var submission models.ChallengeSubmission
 					err := submissionRows.Scan(
 						&submission.ID, &submission.UserID, &submission.PostID, &submission.CreatedAt,
-						&submission.Username, &submission.UserProfileImage,
+						&submission.Username, &submission.UserProfileImage, &submission.LocationStatus,
+						&submission.Place, &submission.PointsAwarded,
 					)
 					if err != nil {
 						log.Printf("Error scanning submission: %v", err)
@@ -807,8 +1281,17 @@ func (h *Handler) GetAllChallenges(w http.ResponseWriter, r *http.Request) {
 		challenges = append(challenges, challenge)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(challenges)
+	if err := h.attachTags(challenges); err != nil {
+		http.Error(w, "Failed to load challenge tags", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.attachPrerequisites(challenges); err != nil {
+		http.Error(w, "Failed to load challenge prerequisites", http.StatusInternalServerError)
+		return
+	}
+
+	writeChallenges(w, r, challenges)
 }
 
 func (h *Handler) UnassignChallenge(w http.ResponseWriter, r *http.Request) {
@@ -819,9 +1302,22 @@ func (h *Handler) UnassignChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.db.Exec(`
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	before, err := auditState(tx, challengeID, 0)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := tx.Exec(`
 		UPDATE challenges 
-		SET assigned_to = NULL, status = 'available'
+		SET assigned_to = NULL, status = 'available', claim_expires_at = NULL
 		WHERE id = ? AND status = 'in_progress'
 	`, challengeID)
 
@@ -841,11 +1337,30 @@ func (h *Handler) UnassignChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	after, err := auditState(tx, challengeID, 0)
+	if err == nil {
+		err = recordAudit(tx, r, "challenge_unassign", "challenge", challengeID, before, after)
+	}
+	if err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
 	log.Printf("Challenge %d unassigned by admin", challengeID)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Challenge unassigned successfully"})
 }
 
+// AwardChallenge ranks the entries of an open challenge. The body lists the
+// winners best first as {"user_ids": [...]} (a single {"user_id": n} still
+// works); each place earns the challenge's placement points and every other
+// valid entry earns the participation points. Challenges decided by a player
+// vote can be re-awarded to override the result.
 func (h *Handler) AwardChallenge(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	challengeID, err := strconv.Atoi(vars["id"])
@@ -855,17 +1370,30 @@ func (h *Handler) AwardChallenge(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		UserID int `json:"user_id"`
+		UserID  int   `json:"user_id"`
+		UserIDs []int `json:"user_ids"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	if req.UserID == 0 {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	ranked := req.UserIDs
+	if len(ranked) == 0 && req.UserID != 0 {
+		ranked = []int{req.UserID}
+	}
+	if len(ranked) == 0 {
+		http.Error(w, "User IDs are required", http.StatusBadRequest)
 		return
 	}
+	seen := make(map[int]bool)
+	for _, userID := range ranked {
+		if userID == 0 || seen[userID] {
+			http.Error(w, "User IDs must be distinct", http.StatusBadRequest)
+			return
+		}
+		seen[userID] = true
+	}
 
 	// Start transaction
 	tx, err := h.db.Begin()
@@ -876,12 +1404,11 @@ func (h *Handler) AwardChallenge(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback()
 
 	// Get challenge information and verify it's an open challenge
-	var challengeType string
-	var challengePoints int
-	var challengeStatus string
+	var challenge models.Challenge
 	err = tx.QueryRow(`
-		SELECT challenge_type, points, status FROM challenges WHERE id = ?
-	`, challengeID).Scan(&challengeType, &challengePoints, &challengeStatus)
+		SELECT `+challengeColumns+`
+		FROM challenges c WHERE c.id = ?
+	`, challengeID).Scan(challengeScanFields(&challenge)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			http.Error(w, "Challenge not found", http.StatusNotFound)
@@ -891,41 +1418,52 @@ func (h *Handler) AwardChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if challengeType != "open" {
+	if challenge.ChallengeType != "open" {
 		http.Error(w, "Only open challenges can be awarded", http.StatusBadRequest)
 		return
 	}
 
-	if challengeStatus == "completed" {
+	if challenge.Status == "completed" && challenge.VotingEndsAt == nil {
 		http.Error(w, "Challenge has already been awarded", http.StatusBadRequest)
 		return
 	}
 
-	// Verify the user has a submission for this challenge
-	var submissionPostID int
-	err = tx.QueryRow(`
-		SELECT post_id FROM challenge_submissions 
-		WHERE challenge_id = ? AND user_id = ? AND post_id > 0
-	`, challengeID, req.UserID).Scan(&submissionPostID)
+	placements := effectivePlacements(challenge)
+	if len(ranked) > len(placements) {
+		http.Error(w, fmt.Sprintf("Challenge only has %d placement(s)", len(placements)), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.openEntries(tx, challenge)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "User has no submission for this challenge", http.StatusNotFound)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	for _, userID := range ranked {
+		if _, ok := entries.posts[userID]; !ok {
+			http.Error(w, fmt.Sprintf("User %d has no valid submission for this challenge", userID), http.StatusNotFound)
 			return
 		}
+	}
+
+	before, err := auditState(tx, challengeID, 0)
+	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	// Note: total_points and challenges_completed are now calculated dynamically from completed challenges
+	awarded, err := awardPlacements(tx, challenge, ranked, entries)
+	if err != nil {
+		http.Error(w, "Failed to award challenge", http.StatusInternalServerError)
+		return
+	}
 
-	// Mark challenge as completed
-	_, err = tx.Exec(`
-		UPDATE challenges 
-		SET status = 'completed', completed_by = ?, completed_post_id = ?, completed_at = CURRENT_TIMESTAMP
-		WHERE id = ?
-	`, req.UserID, submissionPostID, challengeID)
+	after, err := auditState(tx, challengeID, 0)
+	if err == nil {
+		err = recordAudit(tx, r, "challenge_award", "challenge", challengeID, before, after)
+	}
 	if err != nil {
-		http.Error(w, "Failed to update challenge", http.StatusInternalServerError)
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
 		return
 	}
 
@@ -934,7 +1472,10 @@ func (h *Handler) AwardChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log.Printf("Challenge %d successfully awarded to user %d with %d points", challengeID, req.UserID, challengePoints)
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Challenge awarded successfully"})
-}
\ No newline at end of file
+	log.Printf("Challenge %d awarded to users %v (%d placement(s) credited)", challengeID, ranked, len(awarded))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Challenge awarded successfully",
+		"placements": awarded,
+	})
+}
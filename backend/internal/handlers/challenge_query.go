@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"orlando-app/internal/models"
+)
+
+// challengeColumns is the column list shared by every query that returns a
+// full challenge. It expects the challenges table to be aliased as "c" and
+// matches the order of challengeScanFields.
+const challengeColumns = `
+	c.id, c.title, c.description, c.image_url, c.points, c.assigned_to, c.status,
+	c.completed_by, c.completed_post_id, c.completed_at, c.start_date, c.end_date, c.challenge_type,
+	c.geofence_lat, c.geofence_lon, c.geofence_radius_m, c.geofence_park,
+	c.claim_ttl_minutes, c.claim_expires_at, COALESCE(c.claim_extended, FALSE), c.category,
+	c.min_points, COALESCE(c.show_when_locked, FALSE), COALESCE(c.hidden, FALSE), c.unlock_scope, c.unlocked_at,
+	c.placement_points, COALESCE(c.participation_points, 0), c.voting_ends_at, c.voting_closed_at,
+	COALESCE(c.requires_verification, FALSE), c.verification_due_at, COALESCE(c.verification_escalated, FALSE), c.created_at`
+
+func challengeScanFields(challenge *models.Challenge) []interface{} {
+	return []interface{}{
+		&challenge.ID, &challenge.Title, &challenge.Description,
+		&challenge.ImageURL, &challenge.Points, &challenge.AssignedTo,
+		&challenge.Status, &challenge.CompletedBy, &challenge.CompletedPostID,
+		&challenge.CompletedAt, &challenge.StartDate, &challenge.EndDate, &challenge.ChallengeType,
+		&challenge.GeofenceLat, &challenge.GeofenceLon, &challenge.GeofenceRadius, &challenge.GeofencePark,
+		&challenge.ClaimTTLMinutes, &challenge.ClaimExpiresAt, &challenge.ClaimExtended,
+		&challenge.Category, &challenge.MinPoints, &challenge.ShowWhenLocked,
+		&challenge.Hidden, &challenge.UnlockScope, &challenge.UnlockedAt,
+		&challenge.PlacementPoints, &challenge.ParticipationPoints, &challenge.VotingEndsAt, &challenge.VotingClosedAt,
+		&challenge.RequiresVerification, &challenge.VerificationDueAt, &challenge.VerificationEscalated, &challenge.CreatedAt,
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"orlando-app/internal/geo"
+	"orlando-app/internal/models"
+	"orlando-app/internal/tags"
+	"sort"
+	"strings"
+)
+
+// setChallengeTags replaces the tags of a challenge
+func setChallengeTags(db execer, challengeID int, tagList []string) error {
+	if _, err := db.Exec(`DELETE FROM challenge_tags WHERE challenge_id = ?`, challengeID); err != nil {
+		return err
+	}
+	for _, tag := range tagList {
+		if _, err := db.Exec(`INSERT INTO challenge_tags (challenge_id, tag) VALUES (?, ?)`, challengeID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// challengeTags loads the tags of a single challenge
+func (h *Handler) challengeTags(challengeID int) ([]string, error) {
+	rows, err := h.db.Query(`SELECT tag FROM challenge_tags WHERE challenge_id = ? ORDER BY tag`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tagList := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tagList = append(tagList, tag)
+	}
+	return tagList, rows.Err()
+}
+
+// attachTags fills in the tags of every challenge in the list with one query
+func (h *Handler) attachTags(challenges []models.Challenge) error {
+	byChallenge := make(map[int][]string)
+	rows, err := h.db.Query(`SELECT challenge_id, tag FROM challenge_tags ORDER BY tag`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var challengeID int
+		var tag string
+		if err := rows.Scan(&challengeID, &tag); err != nil {
+			return err
+		}
+		byChallenge[challengeID] = append(byChallenge[challengeID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range challenges {
+		challenges[i].Tags = byChallenge[challenges[i].ID]
+		if challenges[i].Tags == nil {
+			challenges[i].Tags = []string{}
+		}
+	}
+	return nil
+}
+
+// normalizeCategory trims a category and treats an empty one as unset
+func normalizeCategory(category *string) *string {
+	if category == nil {
+		return nil
+	}
+	normalized := tags.NormalizeCategory(*category)
+	if normalized == "" {
+		return nil
+	}
+	return &normalized
+}
+
+// tagsFromForm reads the tags form field, which may be repeated, hold a
+// comma-separated list, or both
+func tagsFromForm(r *http.Request) ([]string, error) {
+	var raw []string
+	for _, list := range r.MultipartForm.Value["tags"] {
+		raw = append(raw, tags.Parse(list, ",")...)
+	}
+	return tags.NormalizeAll(raw)
+}
+
+// challengeFilterSQL turns the category, tag and park query parameters into
+// extra conditions on challenges aliased as "c". Repeated tag parameters must
+// all match.
+func challengeFilterSQL(r *http.Request) (string, []interface{}) {
+	query := r.URL.Query()
+	var clause string
+	var args []interface{}
+
+	if category := tags.NormalizeCategory(query.Get("category")); category != "" {
+		clause += ` AND c.category = ?`
+		args = append(args, category)
+	}
+
+	for _, tag := range query["tag"] {
+		if tag = tags.Normalize(tag); tag != "" {
+			clause += ` AND EXISTS (SELECT 1 FROM challenge_tags ct WHERE ct.challenge_id = c.id AND ct.tag = ? COLLATE NOCASE)`
+			args = append(args, tag)
+		}
+	}
+
+	if park := geo.NormalizeParkName(query.Get("park")); park != "" {
+		clause += ` AND (c.geofence_park = ? OR EXISTS (SELECT 1 FROM challenge_tags ct WHERE ct.challenge_id = c.id AND ct.tag = ? COLLATE NOCASE))`
+		args = append(args, park, tags.ParkKey+":"+park)
+	}
+
+	return clause, args
+}
+
+// groupChallenges buckets challenges by category or by the values of a tag
+// key. Challenges with several values for the key appear in each group, and
+// those without one end up in the group with an empty key, listed last.
+// Grouping by park also considers the challenge's geofence park.
+func groupChallenges(challenges []models.Challenge, groupBy string) []models.ChallengeGroup {
+	groups := make(map[string][]models.Challenge)
+	for _, challenge := range challenges {
+		var keys []string
+		switch {
+		case groupBy == "category":
+			if challenge.Category != nil {
+				keys = []string{*challenge.Category}
+			}
+		default:
+			keys = tags.Values(challenge.Tags, groupBy)
+			if len(keys) == 0 && groupBy == tags.ParkKey && challenge.GeofencePark != nil {
+				keys = []string{*challenge.GeofencePark}
+			}
+		}
+		if len(keys) == 0 {
+			keys = []string{""}
+		}
+		for _, key := range keys {
+			groups[key] = append(groups[key], challenge)
+		}
+	}
+
+	result := make([]models.ChallengeGroup, 0, len(groups))
+	for key, grouped := range groups {
+		result = append(result, models.ChallengeGroup{Key: key, Challenges: grouped})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Key == "" || result[j].Key == "" {
+			return result[j].Key == ""
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// writeChallenges encodes a challenge list, grouped when the request asks for
+// it with ?group_by=category or ?group_by=<tag key>
+func writeChallenges(w http.ResponseWriter, r *http.Request, challenges []models.Challenge) {
+	w.Header().Set("Content-Type", "application/json")
+	if groupBy := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("group_by"))); groupBy != "" {
+		json.NewEncoder(w).Encode(groupChallenges(challenges, groupBy))
+		return
+	}
+	json.NewEncoder(w).Encode(challenges)
+}
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// commentColumns selects a comment with its author; comments are aliased as c
+// and users as u
+const commentColumns = `c.id, c.user_id, c.post_id, c.parent_id, COALESCE(c.depth, 0), c.content, c.created_at, c.edited_at,
+	u.username, u.profile_image`
+
+func commentScanFields(comment *models.Comment) []interface{} {
+	return []interface{}{
+		&comment.ID, &comment.UserID, &comment.PostID, &comment.ParentID, &comment.Depth, &comment.Content,
+		&comment.CreatedAt, &comment.EditedAt, &comment.Username, &comment.UserProfileImage,
+	}
+}
+
+// maxCommentDepth bounds how deeply replies nest: top-level comments are at
+// depth 0, so the deepest reply is at maxCommentDepth-1
+const maxCommentDepth = 3
+
+// visibleCommentSQL is true for comments that show under a post: not deleted,
+// removed or hidden by reports. alias is the alias of the comments table.
+func (h *Handler) visibleCommentSQL(alias string) string {
+	return `(` + alias + `.deleted_at IS NULL AND NOT ` + h.hiddenSQL(commentReports, alias) + `)`
+}
+
+// threadCommentSQL is true for comments that show in a thread: visible ones,
+// and hidden or deleted ones that still have a reply showing below them so
+// the thread keeps its shape. depth is the depth of the comments at alias.
+func (h *Handler) threadCommentSQL(alias string, depth int) string {
+	if depth >= maxCommentDepth-1 {
+		return h.visibleCommentSQL(alias)
+	}
+	reply := fmt.Sprintf("tc%d", depth)
+	return `(` + h.visibleCommentSQL(alias) + ` OR EXISTS (SELECT 1 FROM comments ` + reply + ` WHERE ` + reply + `.parent_id = ` +
+		alias + `.id AND ` + h.threadCommentSQL(reply, depth+1) + `))`
+}
+
+// writeThread writes one page of the comments matching filterSQL (for the
+// comments aliased as c) in a thread, oldest first. Each comment carries the
+// number of its replies; hidden comments kept for their replies are blanked.
+func (h *Handler) writeThread(w http.ResponseWriter, r *http.Request, depth int, filterSQL string, args ...interface{}) {
+	page := 1
+	limit := 20
+	if p := r.URL.Query().Get("page"); p != "" {
+		if pageNum, err := strconv.Atoi(p); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if limitNum, err := strconv.Atoi(l); err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
+		}
+	}
+
+	rows, err := h.db.Query(`
+		SELECT `+commentColumns+`, `+h.visibleCommentSQL("c")+`,
+			(SELECT COUNT(*) FROM comments rc WHERE rc.parent_id = c.id AND `+h.threadCommentSQL("rc", depth+1)+`)
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE `+filterSQL+` AND `+h.threadCommentSQL("c", depth)+`
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var comment models.Comment
+		var visible bool
+		err := rows.Scan(append(commentScanFields(&comment), &visible, &comment.ReplyCount)...)
+		if err != nil {
+			http.Error(w, "Failed to scan comment", http.StatusInternalServerError)
+			return
+		}
+		comment.Edited = comment.EditedAt != nil
+		if !visible {
+			comment.Deleted = true
+			comment.Content = ""
+			comment.Edited = false
+			comment.EditedAt = nil
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := h.attachCommentMentions(comments); err != nil {
+		http.Error(w, "Failed to load mentions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments)
+}
+
+// GetCommentReplies pages through the direct replies to a comment
+func (h *Handler) GetCommentReplies(w http.ResponseWriter, r *http.Request) {
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	var depth int
+	if err := h.db.QueryRow(`SELECT COALESCE(depth, 0) FROM comments WHERE id = ?`, commentID).Scan(&depth); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeThread(w, r, depth+1, `c.parent_id = ?`, commentID)
+}
+
+// liveComment is a comment that hasn't been deleted, with its post's author
+type liveComment struct {
+	userID     int
+	postID     int
+	postAuthor int
+	depth      int
+	content    string
+	createdAt  time.Time
+}
+
+func loadLiveComment(db querier, commentID int) (*liveComment, error) {
+	var comment liveComment
+	err := db.QueryRow(`
+		SELECT c.user_id, c.post_id, p.user_id, COALESCE(c.depth, 0), c.content, c.created_at
+		FROM comments c
+		JOIN posts p ON c.post_id = p.id
+		WHERE c.id = ? AND c.deleted_at IS NULL
+	`, commentID).Scan(&comment.userID, &comment.postID, &comment.postAuthor, &comment.depth, &comment.content,
+		&comment.createdAt)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// UpdateComment lets the author change a comment within the edit window. The
+// previous content is kept in the comment's history.
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Content = strings.TrimSpace(req.Content)
+	if req.Content == "" {
+		http.Error(w, "Content is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	existing, err := loadLiveComment(tx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if existing.userID != user.ID {
+		http.Error(w, "You can only edit your own comments", http.StatusForbidden)
+		return
+	}
+	window := time.Duration(h.cfg.CommentEditWindowMinutes) * time.Minute
+	if window > 0 && time.Since(existing.createdAt) > window {
+		http.Error(w, fmt.Sprintf("Comments can only be edited within %d minutes of posting", h.cfg.CommentEditWindowMinutes), http.StatusForbidden)
+		return
+	}
+
+	if req.Content != existing.content {
+		_, err = tx.Exec(`INSERT INTO comment_edits (comment_id, content) VALUES (?, ?)`, commentID, existing.content)
+		if err != nil {
+			http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+			return
+		}
+		_, err = tx.Exec(`UPDATE comments SET content = ?, edited_at = CURRENT_TIMESTAMP WHERE id = ?`, req.Content, commentID)
+		if err != nil {
+			http.Error(w, "Failed to update comment", http.StatusInternalServerError)
+			return
+		}
+
+		// Only players the edit newly mentions are notified
+		message := fmt.Sprintf("%s mentioned you in a comment", user.Username)
+		if err := setMentions(tx, user, existing.postID, &commentID, req.Content, message); err != nil {
+			http.Error(w, "Failed to save mentions", http.StatusInternalServerError)
+			return
+		}
+		if err := setHashtags(tx, existing.postID, &commentID, req.Content); err != nil {
+			http.Error(w, "Failed to save hashtags", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var comment models.Comment
+	err = tx.QueryRow(`
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.id = ?
+	`, commentID).Scan(commentScanFields(&comment)...)
+	if err != nil {
+		http.Error(w, "Failed to fetch updated comment", http.StatusInternalServerError)
+		return
+	}
+	comment.Edited = comment.EditedAt != nil
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	comments := []models.Comment{comment}
+	if err := h.attachCommentMentions(comments); err != nil {
+		http.Error(w, "Failed to load mentions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comments[0])
+}
+
+// DeleteComment soft-deletes a comment. The author, the author of the post
+// and moderators can delete it; the comment author is told when someone else
+// did. Replies are kept, with the deleted comment left as a blank placeholder
+// above them until they are deleted too.
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	comment, err := loadLiveComment(tx, commentID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	moderator := isModerator(user)
+	if comment.userID != user.ID && comment.postAuthor != user.ID && !moderator {
+		http.Error(w, "You cannot delete this comment", http.StatusForbidden)
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE comments SET deleted_at = CURRENT_TIMESTAMP, deleted_by = ? WHERE id = ?
+	`, user.ID, commentID)
+	if err != nil {
+		http.Error(w, "Failed to delete comment", http.StatusInternalServerError)
+		return
+	}
+
+	if comment.userID != user.ID {
+		message := "Your comment was deleted by the author of the post"
+		if comment.postAuthor != user.ID {
+			message = "Your comment was deleted by a moderator"
+			if err := recordAudit(tx, r, "comment_delete", "comment", commentID, nil, nil); err != nil {
+				http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+				return
+			}
+		}
+		if err := notify(tx, comment.userID, "comment_removed", message, nil, &comment.postID); err != nil {
+			http.Error(w, "Failed to notify author", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetCommentHistory lists the earlier versions of a comment, oldest first.
+// Only the author and moderators can see them.
+func (h *Handler) GetCommentHistory(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	commentID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+
+	var authorID int
+	if err := h.db.QueryRow(`SELECT user_id FROM comments WHERE id = ?`, commentID).Scan(&authorID); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Comment not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if authorID != user.ID && !isModerator(user) {
+		http.Error(w, "You cannot view this comment's history", http.StatusForbidden)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT content, edited_at FROM comment_edits WHERE comment_id = ? ORDER BY edited_at, id
+	`, commentID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	history := []models.CommentEdit{}
+	for rows.Next() {
+		var edit models.CommentEdit
+		if err := rows.Scan(&edit.Content, &edit.EditedAt); err != nil {
+			http.Error(w, "Failed to scan comment edit", http.StatusInternalServerError)
+			return
+		}
+		history = append(history, edit)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
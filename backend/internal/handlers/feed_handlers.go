@@ -3,64 +3,122 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"orlando-app/internal/middleware"
 	"orlando-app/internal/models"
+	"orlando-app/internal/scoring"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
+// GetFeed pages through posts, newest first or, with sort=top, ranked by
+// engagement and age. Each page carries a next_cursor to pass back as cursor
+// for the page after it; see feedFilterSQL for the filters. scope=following
+// limits it to players the viewer follows.
 func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
-	page := 1
-	limit := 20
+	var order feedOrder
+	switch r.URL.Query().Get("sort") {
+	case "", "new":
+		order = newestFirst
+	case "top":
+		order = rankedFirst
+	default:
+		http.Error(w, "sort must be 'new' or 'top'", http.StatusBadRequest)
+		return
+	}
 
-	if p := r.URL.Query().Get("page"); p != "" {
-		if pageNum, err := strconv.Atoi(p); err == nil && pageNum > 0 {
-			page = pageNum
+	switch r.URL.Query().Get("scope") {
+	case "", "all":
+		h.writeFeed(w, r, order, "")
+	case "following":
+		user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+		if !ok {
+			http.Error(w, "Log in to see posts from players you follow", http.StatusUnauthorized)
+			return
 		}
+		h.writeFeed(w, r, order, followingSQL, user.ID)
+	default:
+		http.Error(w, "scope must be 'all' or 'following'", http.StatusBadRequest)
 	}
+}
 
+// GetUserPosts pages through one player's posts like the feed does
+func (h *Handler) GetUserPosts(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	var exists int
+	if err := h.db.QueryRow(`SELECT 1 FROM users WHERE id = ?`, userID).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writeFeed(w, r, newestFirst, ` AND p.user_id = ?`, userID)
+}
+
+// writeFeed writes one page of the posts matching the feed filters and
+// scopeSQL, an extra condition on posts aliased as "p", in the given order
+func (h *Handler) writeFeed(w http.ResponseWriter, r *http.Request, order feedOrder, scopeSQL string, scopeArgs ...interface{}) {
+	limit := 20
 	if l := r.URL.Query().Get("limit"); l != "" {
 		if limitNum, err := strconv.Atoi(l); err == nil && limitNum > 0 && limitNum <= 50 {
 			limit = limitNum
 		}
 	}
 
-	offset := (page - 1) * limit
-
 	// Get current user if authenticated
 	var currentUserID *int
 	if user, ok := r.Context().Value(middleware.UserContextKey).(models.User); ok {
 		currentUserID = &user.ID
 	}
 
+	filters, filterArgs, offset, err := h.feedFilterSQL(r, order)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filters += scopeSQL
+	filterArgs = append(filterArgs, scopeArgs...)
+
 	query := `
 		SELECT 
-			p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked,
+			p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked, p.revoke_reason,
 			u.username, u.profile_image, c.title, c.points, c.challenge_type, c.status, c.completed_by,
-			COUNT(DISTINCT l.post_id) as likes_count,
+			COUNT(DISTINCT l.user_id) as likes_count,
 			COUNT(DISTINCT cm.id) as comments_count,
-			CASE WHEN ul.user_id IS NOT NULL THEN 1 ELSE 0 END as user_liked
+			CASE WHEN ul.user_id IS NOT NULL THEN 1 ELSE 0 END as user_liked, ul.reaction,
+			`+order.scoreSQL()+` as score
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
 		JOIN challenges c ON p.challenge_id = c.id
-		LEFT JOIN likes l ON p.id = l.post_id
-		LEFT JOIN comments cm ON p.id = cm.post_id
-		LEFT JOIN likes ul ON p.id = ul.post_id AND ul.user_id = ?
-		GROUP BY p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked,
-				 u.username, u.profile_image, c.title, c.points, c.challenge_type, c.status, c.completed_by, ul.user_id
-		ORDER BY p.created_at DESC
+		LEFT JOIN post_scores ps ON p.id = ps.post_id
+		LEFT JOIN reactions l ON p.id = l.post_id
+		LEFT JOIN comments cm ON p.id = cm.post_id AND `+h.visibleCommentSQL("cm")+`
+		LEFT JOIN reactions ul ON p.id = ul.post_id AND ul.user_id = ?
+		WHERE NOT `+h.hiddenSQL(postReports, "p")+filters+`
+		GROUP BY p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked, p.revoke_reason,
+				 u.username, u.profile_image, c.title, c.points, c.challenge_type, c.status, c.completed_by, ul.user_id, ul.reaction,
+				 ps.rank, ps.engagement
+		ORDER BY `+order.keySQL+` DESC, p.id DESC
 		LIMIT ? OFFSET ?
 	`
 
+	// One extra post tells whether there is another page
 	var rows *sql.Rows
-	var err error
-
 	if currentUserID != nil {
-		rows, err = h.db.Query(query, *currentUserID, limit, offset)
+		rows, err = h.db.Query(query, append(append([]interface{}{*currentUserID}, filterArgs...), limit+1, offset)...)
 	} else {
-		rows, err = h.db.Query(query, nil, limit, offset)
+		rows, err = h.db.Query(query, append(append([]interface{}{nil}, filterArgs...), limit+1, offset)...)
 	}
 
 	if err != nil {
@@ -74,10 +132,10 @@ func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
 		var post models.Post
 		err := rows.Scan(
 			&post.ID, &post.UserID, &post.ChallengeID, &post.MediaURL,
-			&post.MediaType, &post.Caption, &post.CreatedAt, &post.Revoked,
+			&post.MediaType, &post.Caption, &post.CreatedAt, &post.Revoked, &post.RevokeReason,
 			&post.Username, &post.UserProfileImage, &post.ChallengeTitle, &post.ChallengePoints,
 			&post.ChallengeType, &post.ChallengeStatus, &post.ChallengeCompletedBy,
-			&post.LikesCount, &post.CommentsCount, &post.UserLiked,
+			&post.LikesCount, &post.CommentsCount, &post.UserLiked, &post.UserReaction, &post.Score,
 		)
 		if err != nil {
 			http.Error(w, "Failed to scan post", http.StatusInternalServerError)
@@ -86,12 +144,28 @@ func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
 		posts = append(posts, post)
 	}
 
-	if posts == nil {
-		posts = []models.Post{}
+	feed := models.FeedPage{Posts: posts}
+	if feed.Posts == nil {
+		feed.Posts = []models.Post{}
+	}
+	if len(feed.Posts) > limit {
+		feed.Posts = feed.Posts[:limit]
+		nextCursor := order.cursor(feed.Posts[limit-1], offset+limit)
+		feed.NextCursor = &nextCursor
+	}
+	posts = feed.Posts
+
+	if err := h.attachPostMentions(posts); err != nil {
+		http.Error(w, "Failed to load mentions", http.StatusInternalServerError)
+		return
+	}
+	if err := h.attachReactions(posts); err != nil {
+		http.Error(w, "Failed to load reactions", http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(posts)
+	json.NewEncoder(w).Encode(feed)
 }
 
 func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
@@ -107,26 +181,26 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 	var post models.Post
 	err = h.db.QueryRow(`
 		SELECT 
-			p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked,
+			p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked, p.revoke_reason,
 			u.username, u.profile_image, c.title, c.points, c.challenge_type, c.status, c.completed_by,
-			COUNT(DISTINCT l.post_id) as likes_count,
+			COUNT(DISTINCT l.user_id) as likes_count,
 			COUNT(DISTINCT cm.id) as comments_count,
-			CASE WHEN ul.user_id IS NOT NULL THEN 1 ELSE 0 END as user_liked
+			CASE WHEN ul.user_id IS NOT NULL THEN 1 ELSE 0 END as user_liked, ul.reaction
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
 		JOIN challenges c ON p.challenge_id = c.id
-		LEFT JOIN likes l ON p.id = l.post_id
-		LEFT JOIN comments cm ON p.id = cm.post_id
-		LEFT JOIN likes ul ON p.id = ul.post_id AND ul.user_id = ?
+		LEFT JOIN reactions l ON p.id = l.post_id
+		LEFT JOIN comments cm ON p.id = cm.post_id AND `+h.visibleCommentSQL("cm")+`
+		LEFT JOIN reactions ul ON p.id = ul.post_id AND ul.user_id = ?
 		WHERE p.id = ?
-		GROUP BY p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked,
-				 u.username, u.profile_image, c.title, c.points, c.challenge_type, c.status, c.completed_by, ul.user_id
+		GROUP BY p.id, p.user_id, p.challenge_id, p.media_url, p.media_type, p.caption, p.created_at, p.revoked, p.revoke_reason,
+				 u.username, u.profile_image, c.title, c.points, c.challenge_type, c.status, c.completed_by, ul.user_id, ul.reaction
 	`, user.ID, postID).Scan(
 		&post.ID, &post.UserID, &post.ChallengeID, &post.MediaURL,
-		&post.MediaType, &post.Caption, &post.CreatedAt, &post.Revoked,
+		&post.MediaType, &post.Caption, &post.CreatedAt, &post.Revoked, &post.RevokeReason,
 		&post.Username, &post.UserProfileImage, &post.ChallengeTitle, &post.ChallengePoints,
 		&post.ChallengeType, &post.ChallengeStatus, &post.ChallengeCompletedBy,
-		&post.LikesCount, &post.CommentsCount, &post.UserLiked,
+		&post.LikesCount, &post.CommentsCount, &post.UserLiked, &post.UserReaction,
 	)
 
 	if err != nil {
@@ -138,8 +212,18 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	posts := []models.Post{post}
+	if err := h.attachPostMentions(posts); err != nil {
+		http.Error(w, "Failed to load mentions", http.StatusInternalServerError)
+		return
+	}
+	if err := h.attachReactions(posts); err != nil {
+		http.Error(w, "Failed to load reactions", http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(post)
+	json.NewEncoder(w).Encode(posts[0])
 }
 
 func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
@@ -161,13 +245,13 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 
 	// Get post details and verify ownership
 	var post models.Post
-	var challengePoints int
+	var completedPostID *int
 	err = tx.QueryRow(`
-		SELECT p.id, p.user_id, p.challenge_id, c.points
+		SELECT p.id, p.user_id, p.challenge_id, c.challenge_type, c.completed_post_id
 		FROM posts p
 		JOIN challenges c ON p.challenge_id = c.id
 		WHERE p.id = ? AND p.user_id = ?
-	`, postID, user.ID).Scan(&post.ID, &post.UserID, &post.ChallengeID, &challengePoints)
+	`, postID, user.ID).Scan(&post.ID, &post.UserID, &post.ChallengeID, &post.ChallengeType, &completedPostID)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -178,26 +262,64 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Delete post (cascade will handle likes and comments)
-	_, err = tx.Exec(`DELETE FROM posts WHERE id = ?`, postID)
+	// Deleting a completion takes its points away, so it's audited
+	before, err := auditState(tx, post.ChallengeID, postID)
 	if err != nil {
-		http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+		http.Error(w, "Database error", http.StatusInternalServerError)
 		return
 	}
 
-	// Return challenge to available pool
-	_, err = tx.Exec(`
-		UPDATE challenges 
-		SET assigned_to = NULL, status = 'available'
-		WHERE id = ?
-	`, post.ChallengeID)
-	if err != nil {
-		http.Error(w, "Failed to update challenge", http.StatusInternalServerError)
-		return
+	// Foreign keys aren't enforced, so the post's rows and those of its
+	// comments are deleted with it. Deleting an open challenge entry only
+	// drops its own placement.
+	commentIDs := `SELECT id FROM comments WHERE post_id = ?`
+	for _, query := range []string{
+		`DELETE FROM comment_reports WHERE comment_id IN (` + commentIDs + `)`,
+		`DELETE FROM comment_edits WHERE comment_id IN (` + commentIDs + `)`,
+		`DELETE FROM mentions WHERE comment_id IN (` + commentIDs + `)`,
+		`DELETE FROM hashtags WHERE comment_id IN (` + commentIDs + `)`,
+		`DELETE FROM comments WHERE post_id = ?`,
+		`DELETE FROM mentions WHERE post_id = ?`,
+		`DELETE FROM hashtags WHERE post_id = ?`,
+		`DELETE FROM reactions WHERE post_id = ?`,
+		`DELETE FROM likes WHERE post_id = ?`,
+		`DELETE FROM post_scores WHERE post_id = ?`,
+		`DELETE FROM post_reports WHERE post_id = ?`,
+		`DELETE FROM challenge_placements WHERE post_id = ?`,
+		`DELETE FROM challenge_votes WHERE post_id = ?`,
+		`DELETE FROM completion_reviews WHERE post_id = ?`,
+		`DELETE FROM posts WHERE id = ?`,
+	} {
+		if _, err := tx.Exec(query, postID); err != nil {
+			http.Error(w, "Failed to delete post", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// An exclusive challenge goes back to the pool when this was its completion
+	if post.ChallengeType == "exclusive" && completedPostID != nil && *completedPostID == postID {
+		_, err = tx.Exec(`
+			UPDATE challenges 
+			SET assigned_to = NULL, status = 'available', completed_by = NULL, completed_post_id = NULL, completed_at = NULL, claim_expires_at = NULL
+			WHERE id = ?
+		`, post.ChallengeID)
+		if err != nil {
+			http.Error(w, "Failed to update challenge", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	// Note: total_points and challenges_completed are now calculated dynamically from completed challenges
 
+	after, err := auditState(tx, post.ChallengeID, 0)
+	if err == nil {
+		err = recordAudit(tx, r, "post_delete", "post", postID, before, after)
+	}
+	if err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
 	if err = tx.Commit(); err != nil {
 		http.Error(w, "Failed to complete transaction", http.StatusInternalServerError)
 		return
@@ -206,6 +328,8 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// LikePost leaves a heart on a post. It keeps any other reaction the player
+// already left; use SetReaction to change it.
 func (h *Handler) LikePost(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(models.User)
 	vars := mux.Vars(r)
@@ -216,9 +340,9 @@ func (h *Handler) LikePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, err = h.db.Exec(`
-		INSERT OR IGNORE INTO likes (user_id, post_id)
-		VALUES (?, ?)
-	`, user.ID, postID)
+		INSERT OR IGNORE INTO reactions (user_id, post_id, reaction)
+		VALUES (?, ?, ?)
+	`, user.ID, postID, defaultReaction)
 
 	if err != nil {
 		http.Error(w, "Failed to like post", http.StatusInternalServerError)
@@ -229,6 +353,7 @@ func (h *Handler) LikePost(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Post liked successfully"})
 }
 
+// UnlikePost removes the player's reaction from a post, whichever it is
 func (h *Handler) UnlikePost(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(models.User)
 	vars := mux.Vars(r)
@@ -239,7 +364,7 @@ func (h *Handler) UnlikePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, err = h.db.Exec(`
-		DELETE FROM likes WHERE user_id = ? AND post_id = ?
+		DELETE FROM reactions WHERE user_id = ? AND post_id = ?
 	`, user.ID, postID)
 
 	if err != nil {
@@ -251,6 +376,8 @@ func (h *Handler) UnlikePost(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"message": "Post unliked successfully"})
 }
 
+// GetComments pages through the top-level comments of a post; replies are
+// loaded per comment from /comments/{id}/replies
 func (h *Handler) GetComments(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postID, err := strconv.Atoi(vars["id"])
@@ -259,40 +386,7 @@ func (h *Handler) GetComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	rows, err := h.db.Query(`
-		SELECT c.id, c.user_id, c.post_id, c.content, c.created_at, u.username, u.profile_image
-		FROM comments c
-		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = ?
-		ORDER BY c.created_at ASC
-	`, postID)
-
-	if err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
-	}
-	defer rows.Close()
-
-	var comments []models.Comment
-	for rows.Next() {
-		var comment models.Comment
-		err := rows.Scan(
-			&comment.ID, &comment.UserID, &comment.PostID,
-			&comment.Content, &comment.CreatedAt, &comment.Username, &comment.UserProfileImage,
-		)
-		if err != nil {
-			http.Error(w, "Failed to scan comment", http.StatusInternalServerError)
-			return
-		}
-		comments = append(comments, comment)
-	}
-
-	if comments == nil {
-		comments = []models.Comment{}
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(comments)
+	h.writeThread(w, r, 0, `c.post_id = ? AND c.parent_id IS NULL`, postID)
 }
 
 func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
@@ -315,34 +409,83 @@ func (h *Handler) CreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Replies go under a live comment on the same post, up to maxCommentDepth deep
+	depth := 0
+	if req.ParentID != nil {
+		parent, err := loadLiveComment(h.db, *req.ParentID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				http.Error(w, "Parent comment not found", http.StatusNotFound)
+				return
+			}
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if parent.postID != postID {
+			http.Error(w, "Parent comment belongs to another post", http.StatusBadRequest)
+			return
+		}
+		depth = parent.depth + 1
+		if depth >= maxCommentDepth {
+			http.Error(w, fmt.Sprintf("Replies can only be nested %d levels deep", maxCommentDepth), http.StatusBadRequest)
+			return
+		}
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
 	var commentID int
-	err = h.db.QueryRow(`
-		INSERT INTO comments (user_id, post_id, content)
-		VALUES (?, ?, ?)
+	err = tx.QueryRow(`
+		INSERT INTO comments (user_id, post_id, content, parent_id, depth)
+		VALUES (?, ?, ?, ?, ?)
 		RETURNING id
-	`, user.ID, postID, req.Content).Scan(&commentID)
+	`, user.ID, postID, req.Content, req.ParentID, depth).Scan(&commentID)
 
 	if err != nil {
 		http.Error(w, "Failed to create comment", http.StatusInternalServerError)
 		return
 	}
 
+	message := fmt.Sprintf("%s mentioned you in a comment", user.Username)
+	if err := setMentions(tx, user, postID, &commentID, req.Content, message); err != nil {
+		http.Error(w, "Failed to save mentions", http.StatusInternalServerError)
+		return
+	}
+	if err := setHashtags(tx, postID, &commentID, req.Content); err != nil {
+		http.Error(w, "Failed to save hashtags", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
 	var comment models.Comment
 	err = h.db.QueryRow(`
-		SELECT c.id, c.user_id, c.post_id, c.content, c.created_at, u.username, u.profile_image
+		SELECT `+commentColumns+`
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
 		WHERE c.id = ?
-	`, commentID).Scan(
-		&comment.ID, &comment.UserID, &comment.PostID,
-		&comment.Content, &comment.CreatedAt, &comment.Username, &comment.UserProfileImage,
-	)
+	`, commentID).Scan(commentScanFields(&comment)...)
 
 	if err != nil {
 		http.Error(w, "Failed to fetch created comment", http.StatusInternalServerError)
 		return
 	}
 
+	comments := []models.Comment{comment}
+	if err := h.attachCommentMentions(comments); err != nil {
+		http.Error(w, "Failed to load mentions", http.StatusInternalServerError)
+		return
+	}
+	comment = comments[0]
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(comment)
@@ -352,20 +495,8 @@ func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 	rows, err := h.db.Query(`
 		SELECT 
 			u.id, u.username, u.first_name, u.last_name, u.profile_image,
-			COALESCE(SUM(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN c.points 
-				ELSE 0 
-			END), 0) as total_points,
-			COUNT(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN p.id 
-				ELSE NULL 
-			END) as challenges_completed
+			`+scoring.PointsSQL+` as total_points,
+			`+scoring.CompletedSQL+` as challenges_completed
 		FROM users u
 		LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 		LEFT JOIN challenges c ON p.challenge_id = c.id
@@ -402,7 +533,9 @@ func (h *Handler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(users)
 }
 
-// Admin function to revoke points from a post
+// Admin function to revoke points from a post. The reason is stored on the post.
+// Revoking an entry on an open challenge leaves the other placements alone
+// unless reaward is set to take the challenge back and award it again.
 func (h *Handler) RevokePostPoints(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postID, err := strconv.Atoi(vars["id"])
@@ -411,6 +544,20 @@ func (h *Handler) RevokePostPoints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var req struct {
+		Reason  string `json:"reason"`
+		Reaward bool   `json:"reaward"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		http.Error(w, "Reason is required", http.StatusBadRequest)
+		return
+	}
+
 	// Start transaction
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -424,11 +571,11 @@ func (h *Handler) RevokePostPoints(w http.ResponseWriter, r *http.Request) {
 	var challengePoints int
 	var originalUserID int
 	err = tx.QueryRow(`
-		SELECT p.id, p.user_id, p.challenge_id, c.points
+		SELECT p.id, p.user_id, p.challenge_id, c.points, c.challenge_type, p.revoked
 		FROM posts p
 		JOIN challenges c ON p.challenge_id = c.id
 		WHERE p.id = ?
-	`, postID).Scan(&post.ID, &originalUserID, &post.ChallengeID, &challengePoints)
+	`, postID).Scan(&post.ID, &originalUserID, &post.ChallengeID, &challengePoints, &post.ChallengeType, &post.Revoked)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -439,27 +586,240 @@ func (h *Handler) RevokePostPoints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Return challenge to available pool for any user to pick up
+	if post.Revoked {
+		http.Error(w, "Post has already been revoked", http.StatusConflict)
+		return
+	}
+
+	before, err := auditState(tx, post.ChallengeID, postID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := revokePost(tx, post.ChallengeID, postID, req.Reason, req.Reaward); err != nil {
+		http.Error(w, "Failed to revoke post", http.StatusInternalServerError)
+		return
+	}
+
+	admin := r.Context().Value(middleware.UserContextKey).(models.User)
 	_, err = tx.Exec(`
-		UPDATE challenges 
-		SET assigned_to = NULL, status = 'available', completed_by = NULL, completed_post_id = NULL, completed_at = NULL
-		WHERE id = ?
-	`, post.ChallengeID)
+		INSERT INTO moderation_actions (post_id, moderator_id, action, reason) VALUES (?, ?, 'revoke', ?)
+	`, postID, admin.ID, req.Reason)
 	if err != nil {
-		http.Error(w, "Failed to reassign challenge", http.StatusInternalServerError)
+		http.Error(w, "Failed to revoke post", http.StatusInternalServerError)
+		return
+	}
+
+	after, err := auditState(tx, post.ChallengeID, postID)
+	if err == nil {
+		err = recordAudit(tx, r, "post_revoke", "post", postID, before, after)
+	}
+	if err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
+	if err = tx.Commit(); err != nil {
+		http.Error(w, "Failed to complete transaction", http.StatusInternalServerError)
 		return
 	}
 
+	message := "Points revoked successfully. Challenge returned to available pool."
+	if post.ChallengeType == "open" && !req.Reaward {
+		message = "Points revoked successfully. Other placements are unchanged."
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+// revokePost marks a post as revoked for the given reason. An exclusive
+// challenge goes back to the pool. On an open challenge only the post's own
+// placement is dropped and the other players keep theirs, unless reaward is
+// set: then the challenge goes back to being unawarded, its placements are
+// void and a finished vote is counted again without the revoked post.
+func revokePost(tx *sql.Tx, challengeID, postID int, reason string, reaward bool) error {
+	var challengeType string
+	if err := tx.QueryRow(`SELECT challenge_type FROM challenges WHERE id = ?`, challengeID).Scan(&challengeType); err != nil {
+		return err
+	}
+
+	if challengeType == "exclusive" || reaward {
+		// Return challenge to available pool for any user to pick up
+		_, err := tx.Exec(`
+			UPDATE challenges 
+			SET assigned_to = NULL, status = 'available', completed_by = NULL, completed_post_id = NULL, completed_at = NULL, claim_expires_at = NULL
+			WHERE id = ?
+		`, challengeID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if challengeType == "open" {
+		if reaward {
+			if _, err := tx.Exec(`DELETE FROM challenge_placements WHERE challenge_id = ?`, challengeID); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`UPDATE challenges SET voting_closed_at = NULL WHERE id = ?`, challengeID); err != nil {
+				return err
+			}
+		} else {
+			_, err := tx.Exec(`DELETE FROM challenge_placements WHERE challenge_id = ? AND post_id = ?`, challengeID, postID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	// Note: total_points and challenges_completed are now calculated dynamically from completed challenges
 
 	// Mark the post as revoked
-	_, err = tx.Exec(`
+	_, err := tx.Exec(`
 		UPDATE posts 
-		SET revoked = TRUE
+		SET revoked = TRUE, revoke_reason = ?, revoked_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, nullIfEmpty(reason), postID)
+	return err
+}
+
+// RestorePost undoes a revoke. An exclusive challenge is credited to the
+// original completer again as long as nobody has picked or completed it
+// since; an open challenge gets the entry back unless the player has
+// submitted another one. Anything else is reported as a conflict.
+func (h *Handler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	admin := r.Context().Value(middleware.UserContextKey).(models.User)
+	vars := mux.Vars(r)
+	postID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var post models.Post
+	var assignedTo, completedBy *int
+	err = tx.QueryRow(`
+		SELECT p.id, p.user_id, p.challenge_id, p.revoked, p.created_at,
+			c.title, c.challenge_type, c.status, c.assigned_to, c.completed_by
+		FROM posts p
+		JOIN challenges c ON p.challenge_id = c.id
+		WHERE p.id = ?
+	`, postID).Scan(&post.ID, &post.UserID, &post.ChallengeID, &post.Revoked, &post.CreatedAt,
+		&post.ChallengeTitle, &post.ChallengeType, &post.ChallengeStatus, &assignedTo, &completedBy)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if !post.Revoked {
+		http.Error(w, "Post is not revoked", http.StatusConflict)
+		return
+	}
+
+	conflict := func(message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":            message,
+			"challenge_id":     post.ChallengeID,
+			"challenge_status": post.ChallengeStatus,
+			"assigned_to":      assignedTo,
+			"completed_by":     completedBy,
+		})
+	}
+
+	before, err := auditState(tx, post.ChallengeID, postID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	message := "Post restored and points credited again"
+	if post.ChallengeType == "exclusive" {
+		if post.ChallengeStatus != "available" || assignedTo != nil {
+			conflict("Challenge has been picked or completed again since the post was revoked")
+			return
+		}
+
+		_, err = tx.Exec(`
+			UPDATE challenges
+			SET status = 'completed', completed_by = ?, completed_post_id = ?, completed_at = ?, claim_expires_at = NULL
+			WHERE id = ?
+		`, post.UserID, postID, post.CreatedAt, post.ChallengeID)
+		if err != nil {
+			http.Error(w, "Failed to restore challenge", http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var entryPostID sql.NullInt64
+		err = tx.QueryRow(`
+			SELECT post_id FROM challenge_submissions WHERE challenge_id = ? AND user_id = ?
+		`, post.ChallengeID, post.UserID).Scan(&entryPostID)
+		if err != nil && err != sql.ErrNoRows {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if entryPostID.Valid && entryPostID.Int64 > 0 && int(entryPostID.Int64) != postID {
+			conflict("Player has submitted another entry since the post was revoked")
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			_, err = tx.Exec(`
+				INSERT INTO challenge_submissions (challenge_id, user_id, post_id) VALUES (?, ?, ?)
+			`, post.ChallengeID, post.UserID, postID)
+		} else {
+			_, err = tx.Exec(`
+				UPDATE challenge_submissions SET post_id = ? WHERE challenge_id = ? AND user_id = ?
+			`, postID, post.ChallengeID, post.UserID)
+		}
+		if err != nil {
+			http.Error(w, "Failed to restore submission", http.StatusInternalServerError)
+			return
+		}
+
+		// Open challenges are only credited through placements, and the
+		// revoke dropped this post's
+		message = "Post restored as an entry; award the challenge to credit it"
+	}
+
+	_, err = tx.Exec(`
+		UPDATE posts SET revoked = FALSE, revoke_reason = NULL, revoked_at = NULL, moderation_status = 'approved'
 		WHERE id = ?
 	`, postID)
 	if err != nil {
-		http.Error(w, "Failed to mark post as revoked", http.StatusInternalServerError)
+		http.Error(w, "Failed to restore post", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := tx.Exec(`INSERT INTO moderation_actions (post_id, moderator_id, action) VALUES (?, ?, 'restore')`, postID, admin.ID); err != nil {
+		http.Error(w, "Failed to restore post", http.StatusInternalServerError)
+		return
+	}
+
+	after, err := auditState(tx, post.ChallengeID, postID)
+	if err == nil {
+		err = recordAudit(tx, r, "post_restore", "post", postID, before, after)
+	}
+	if err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
+	notice := fmt.Sprintf("Your post for \"%s\" was restored by an admin", post.ChallengeTitle)
+	if err := notify(tx, post.UserID, "post_restored", notice, &post.ChallengeID, &postID); err != nil {
+		http.Error(w, "Failed to notify author", http.StatusInternalServerError)
 		return
 	}
 
@@ -468,6 +828,6 @@ func (h *Handler) RevokePostPoints(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Points revoked successfully. Challenge returned to available pool."})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
 }
\ No newline at end of file
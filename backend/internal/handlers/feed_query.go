@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"orlando-app/internal/models"
+	"orlando-app/internal/tags"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// feedOrder is a way of ordering the feed: by key, highest first, with the
+// post id breaking ties. Pages are keyed on both, so a page picks up after
+// the previous one no matter how many posts were added since. Scored orders
+// page by position instead: scores are refreshed in the background, so a
+// post's score can't mark where the previous page ended.
+type feedOrder struct {
+	keySQL string // sort key of posts aliased as p, with post_scores as ps
+	scored bool   // the key is a score from post_scores rather than created_at
+}
+
+var (
+	newestFirst = feedOrder{keySQL: "p.created_at"}
+	// rankedFirst weighs engagement against age; see refreshPostScores
+	rankedFirst = feedOrder{keySQL: "COALESCE(ps.rank, 0)", scored: true}
+	// mostEngaged ignores age, for top lists over a fixed period
+	mostEngaged = feedOrder{keySQL: "COALESCE(ps.engagement, 0)", scored: true}
+)
+
+// scoreSQL selects the post's score for scored orders
+func (o feedOrder) scoreSQL() string {
+	if o.scored {
+		return o.keySQL
+	}
+	return "NULL"
+}
+
+// cursor turns the last post of a page into the opaque string clients send
+// back for the next one. next is how many posts the pages so far covered,
+// which is what scored orders page by.
+func (o feedOrder) cursor(post models.Post, next int) string {
+	raw := strconv.FormatInt(post.CreatedAt.Unix(), 10) + ":" + strconv.Itoa(post.ID)
+	if o.scored {
+		raw = "o:" + strconv.Itoa(next)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// afterCursorSQL is true for posts after the one the cursor was made from.
+// For scored orders it returns how many posts to skip instead.
+func (o feedOrder) afterCursorSQL(value string) (string, []interface{}, int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 || (parts[0] == "o") != o.scored {
+		return "", nil, 0, fmt.Errorf("malformed cursor")
+	}
+
+	if o.scored {
+		offset, err := strconv.Atoi(parts[1])
+		if err != nil || offset < 0 {
+			return "", nil, 0, fmt.Errorf("malformed cursor")
+		}
+		return "", nil, offset, nil
+	}
+
+	id, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", nil, 0, err
+	}
+	seconds, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	key := time.Unix(seconds, 0).UTC().Format("2006-01-02 15:04:05")
+	return ` AND (` + o.keySQL + ` < ? OR (` + o.keySQL + ` = ? AND p.id < ?))`, []interface{}{key, key, id}, 0, nil
+}
+
+// feedFilterSQL turns the feed's query parameters into extra conditions on
+// posts aliased as "p" and challenges as "c": cursor, tag, user_id,
+// challenge_id, media_type, challenge_type and exclude_revoked=true. It also
+// returns the offset a scored order's cursor points at.
+func (h *Handler) feedFilterSQL(r *http.Request, order feedOrder) (string, []interface{}, int, error) {
+	query := r.URL.Query()
+	var clause string
+	var args []interface{}
+	var offset int
+
+	if value := query.Get("cursor"); value != "" {
+		cursorSQL, cursorArgs, cursorOffset, err := order.afterCursorSQL(value)
+		if err != nil {
+			return "", nil, 0, fmt.Errorf("Invalid cursor")
+		}
+		clause += cursorSQL
+		args = append(args, cursorArgs...)
+		offset = cursorOffset
+	}
+
+	// tag=day3 (or #day3) limits the feed to posts using that hashtag
+	if value := query.Get("tag"); value != "" {
+		tag := tags.NormalizeHashtag(value)
+		if tag == "" {
+			return "", nil, 0, fmt.Errorf("Invalid tag")
+		}
+		clause += ` AND ` + h.hashtagFilterSQL()
+		args = append(args, tag)
+	}
+
+	for _, filter := range []struct{ param, column string }{
+		{"user_id", "p.user_id"},
+		{"challenge_id", "p.challenge_id"},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				return "", nil, 0, fmt.Errorf("Invalid %s", filter.param)
+			}
+			clause += ` AND ` + filter.column + ` = ?`
+			args = append(args, id)
+		}
+	}
+
+	for _, filter := range []struct {
+		param, column string
+		allowed       []string
+	}{
+		{"media_type", "p.media_type", []string{"photo", "video"}},
+		{"challenge_type", "c.challenge_type", []string{"exclusive", "open"}},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			if !containsString(filter.allowed, value) {
+				return "", nil, 0, fmt.Errorf("%s must be one of: %s", filter.param, strings.Join(filter.allowed, ", "))
+			}
+			clause += ` AND ` + filter.column + ` = ?`
+			args = append(args, value)
+		}
+	}
+
+	if query.Get("exclude_revoked") == "true" {
+		clause += ` AND NOT COALESCE(p.revoked, FALSE)`
+	}
+
+	return clause, args, offset, nil
+}
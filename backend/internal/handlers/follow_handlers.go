@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// followingSQL limits the feed to posts (aliased as p) by players the viewer
+// follows
+const followingSQL = ` AND p.user_id IN (SELECT followee_id FROM follows WHERE follower_id = ?)`
+
+// attachFollowCounts fills in how many players follow the user and how many
+// they follow, and whether viewerID follows them when it's someone else
+func attachFollowCounts(db querier, user *models.User, viewerID int) error {
+	err := db.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM follows WHERE followee_id = ?),
+			(SELECT COUNT(*) FROM follows WHERE follower_id = ?)
+	`, user.ID, user.ID).Scan(&user.FollowersCount, &user.FollowingCount)
+	if err != nil {
+		return err
+	}
+
+	if viewerID != 0 && viewerID != user.ID {
+		var following bool
+		err := db.QueryRow(`
+			SELECT EXISTS (SELECT 1 FROM follows WHERE follower_id = ? AND followee_id = ?)
+		`, viewerID, user.ID).Scan(&following)
+		if err != nil {
+			return err
+		}
+		user.IsFollowing = &following
+	}
+	return nil
+}
+
+// FollowUser follows a player so their posts show in the following feed.
+// They are told the first time.
+func (h *Handler) FollowUser(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	followeeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	if followeeID == user.ID {
+		http.Error(w, "You cannot follow yourself", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow(`SELECT 1 FROM users WHERE id = ?`, followeeID).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT OR IGNORE INTO follows (follower_id, followee_id) VALUES (?, ?)
+	`, user.ID, followeeID)
+	if err != nil {
+		http.Error(w, "Failed to follow user", http.StatusInternalServerError)
+		return
+	}
+	if added, err := result.RowsAffected(); err == nil && added > 0 {
+		message := fmt.Sprintf("%s started following you", user.Username)
+		if err := notify(tx, followeeID, "follow", message, nil, nil); err != nil {
+			http.Error(w, "Failed to notify user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "User followed"})
+}
+
+// UnfollowUser stops following a player
+func (h *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	followeeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.db.Exec(`DELETE FROM follows WHERE follower_id = ? AND followee_id = ?`, user.ID, followeeID)
+	if err != nil {
+		http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "User unfollowed"})
+}
+
+// GetFollowers pages through the players following a player, newest first
+func (h *Handler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	h.writeFollowList(w, r, "followee_id", "follower_id")
+}
+
+// GetFollowing pages through the players a player follows, newest first
+func (h *Handler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	h.writeFollowList(w, r, "follower_id", "followee_id")
+}
+
+// writeFollowList lists the players in listColumn of the follows where
+// userColumn is the player in the URL
+func (h *Handler) writeFollowList(w http.ResponseWriter, r *http.Request, userColumn, listColumn string) {
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	page := 1
+	limit := 50
+	if p := r.URL.Query().Get("page"); p != "" {
+		if pageNum, err := strconv.Atoi(p); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if limitNum, err := strconv.Atoi(l); err == nil && limitNum > 0 && limitNum <= 100 {
+			limit = limitNum
+		}
+	}
+
+	var exists int
+	if err := h.db.QueryRow(`SELECT 1 FROM users WHERE id = ?`, userID).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	list := models.FollowList{Users: []models.FollowEntry{}, Page: page, Limit: limit}
+	err = h.db.QueryRow(`SELECT COUNT(*) FROM follows WHERE `+userColumn+` = ?`, userID).Scan(&list.Total)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT u.id, u.username, u.profile_image, f.created_at
+		FROM follows f
+		JOIN users u ON f.`+listColumn+` = u.id
+		WHERE f.`+userColumn+` = ?
+		ORDER BY f.created_at DESC, u.id
+		LIMIT ? OFFSET ?
+	`, userID, limit, (page-1)*limit)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.FollowEntry
+		if err := rows.Scan(&entry.UserID, &entry.Username, &entry.ProfileImage, &entry.FollowedAt); err != nil {
+			http.Error(w, "Failed to scan user", http.StatusInternalServerError)
+			return
+		}
+		list.Users = append(list.Users, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
@@ -10,6 +10,7 @@ import (
 	"orlando-app/internal/config"
 	"orlando-app/internal/middleware"
 	"orlando-app/internal/models"
+	"orlando-app/internal/scoring"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -83,20 +84,8 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	err = h.db.QueryRow(`
 		SELECT 
 			u.id, u.username, u.first_name, u.last_name, u.profile_image, u.role, u.created_at,
-			COALESCE(SUM(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN c.points 
-				ELSE 0 
-			END), 0) as total_points,
-			COUNT(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN p.id 
-				ELSE NULL 
-			END) as challenges_completed
+			`+scoring.PointsSQL+` as total_points,
+			`+scoring.CompletedSQL+` as challenges_completed
 		FROM users u
 		LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 		LEFT JOIN challenges c ON p.challenge_id = c.id
@@ -139,20 +128,8 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	err := h.db.QueryRow(`
 		SELECT 
 			u.id, u.username, u.password_hash, u.first_name, u.last_name, u.profile_image, u.role, u.created_at,
-			COALESCE(SUM(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN c.points 
-				ELSE 0 
-			END), 0) as total_points,
-			COUNT(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN p.id 
-				ELSE NULL 
-			END) as challenges_completed
+			`+scoring.PointsSQL+` as total_points,
+			`+scoring.CompletedSQL+` as challenges_completed
 		FROM users u
 		LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 		LEFT JOIN challenges c ON p.challenge_id = c.id
@@ -204,20 +181,8 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	err := h.db.QueryRow(`
 		SELECT 
 			u.id, u.username, u.first_name, u.last_name, u.profile_image, u.role, u.created_at,
-			COALESCE(SUM(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN c.points 
-				ELSE 0 
-			END), 0) as total_points,
-			COUNT(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN p.id 
-				ELSE NULL 
-			END) as challenges_completed
+			`+scoring.PointsSQL+` as total_points,
+			`+scoring.CompletedSQL+` as challenges_completed
 		FROM users u
 		LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 		LEFT JOIN challenges c ON p.challenge_id = c.id
@@ -233,6 +198,10 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to fetch user profile", http.StatusInternalServerError)
 		return
 	}
+	if err := attachFollowCounts(h.db, &user, user.ID); err != nil {
+		http.Error(w, "Failed to fetch user profile", http.StatusInternalServerError)
+		return
+	}
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
@@ -322,20 +291,8 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	err = h.db.QueryRow(`
 		SELECT 
 			u.id, u.username, u.first_name, u.last_name, u.profile_image, u.role, u.created_at,
-			COALESCE(SUM(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN c.points 
-				ELSE 0 
-			END), 0) as total_points,
-			COUNT(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN p.id 
-				ELSE NULL 
-			END) as challenges_completed
+			`+scoring.PointsSQL+` as total_points,
+			`+scoring.CompletedSQL+` as challenges_completed
 		FROM users u
 		LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 		LEFT JOIN challenges c ON p.challenge_id = c.id
@@ -351,6 +308,10 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to fetch updated user", http.StatusInternalServerError)
 		return
 	}
+	if err := attachFollowCounts(h.db, &user, user.ID); err != nil {
+		http.Error(w, "Failed to fetch updated user", http.StatusInternalServerError)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
@@ -368,20 +329,8 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 	err = h.db.QueryRow(`
 		SELECT 
 			u.id, u.username, u.first_name, u.last_name, u.profile_image, u.role, u.created_at,
-			COALESCE(SUM(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN c.points 
-				ELSE 0 
-			END), 0) as total_points,
-			COUNT(CASE 
-				WHEN c.status = 'completed' AND 
-					 ((c.challenge_type = 'exclusive') OR 
-					  (c.challenge_type = 'open' AND c.completed_by = u.id))
-				THEN p.id 
-				ELSE NULL 
-			END) as challenges_completed
+			`+scoring.PointsSQL+` as total_points,
+			`+scoring.CompletedSQL+` as challenges_completed
 		FROM users u
 		LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 		LEFT JOIN challenges c ON p.challenge_id = c.id
@@ -402,6 +351,36 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rows, err := h.db.Query(`
+		SELECT c.challenge_type, COUNT(*)
+		FROM posts p
+		JOIN challenges c ON p.challenge_id = c.id
+		WHERE p.user_id = ? AND NOT `+h.hiddenSQL(postReports, "p")+`
+		GROUP BY c.challenge_type
+	`, userID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	viewer := r.Context().Value(middleware.UserContextKey).(models.User)
+	if err := attachFollowCounts(h.db, &user, viewer.ID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	user.PostsByChallengeType = map[string]int{"exclusive": 0, "open": 0}
+	for rows.Next() {
+		var challengeType string
+		var count int
+		if err := rows.Scan(&challengeType, &count); err != nil {
+			http.Error(w, "Failed to scan post counts", http.StatusInternalServerError)
+			return
+		}
+		user.PostsByChallengeType[challengeType] = count
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
@@ -410,11 +389,10 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
 	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	filterSQL, filterArgs := challengeFilterSQL(r)
 
 	rows, err := h.db.Query(`
-		SELECT 
-			c.id, c.title, c.description, c.image_url, c.points, c.assigned_to, c.status, 
-			c.completed_by, c.completed_post_id, c.completed_at, c.start_date, c.end_date, c.challenge_type, c.created_at,
+		SELECT `+challengeColumns+`,
 			u.username as completed_by_username
 		FROM challenges c
 		LEFT JOIN users u ON c.completed_by = u.id
@@ -423,13 +401,22 @@ func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
 			(c.challenge_type = 'exclusive' AND (
 				(c.status = 'available' AND c.assigned_to IS NULL) OR 
 				(c.status = 'in_progress' AND c.assigned_to = ?) OR 
-				c.status = 'completed'
+				c.status IN ('completed', 'pending_verification')
 			))
 		)
 		AND (c.start_date IS NULL OR c.start_date <= CURRENT_TIMESTAMP)
-		AND (c.end_date IS NULL OR c.end_date >= CURRENT_TIMESTAMP)
+		AND (
+			(c.end_date IS NULL OR c.end_date >= CURRENT_TIMESTAMP) OR
+			-- Keep showing challenges the user still holds during the grace period
+			(`+h.graceWindowSQL()+` AND (
+				c.assigned_to = ? OR
+				EXISTS (SELECT 1 FROM challenge_submissions cs WHERE cs.challenge_id = c.id AND cs.user_id = ? AND cs.post_id = 0)
+			))
+		)
+		AND `+unlockedSQL+`
+		`+filterSQL+`
 		ORDER BY c.created_at DESC
-	`, user.ID)
+	`, append([]interface{}{user.ID, user.ID, user.ID, user.ID}, filterArgs...)...)
 
 	if err != nil {
 		http.Error(w, "Database error", http.StatusInternalServerError)
@@ -440,12 +427,7 @@ func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
 	var challenges []models.Challenge
 	for rows.Next() {
 		var challenge models.Challenge
-		err := rows.Scan(
-			&challenge.ID, &challenge.Title, &challenge.Description,
-			&challenge.ImageURL, &challenge.Points, &challenge.AssignedTo,
-			&challenge.Status, &challenge.CompletedBy, &challenge.CompletedPostID,
-			&challenge.CompletedAt, &challenge.StartDate, &challenge.EndDate, &challenge.ChallengeType, &challenge.CreatedAt, &challenge.CompletedByUsername,
-		)
+		err := rows.Scan(append(challengeScanFields(&challenge), &challenge.CompletedByUsername)...)
 		if err != nil {
 			http.Error(w, "Failed to scan challenge", http.StatusInternalServerError)
 			return
@@ -456,9 +438,10 @@ func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
 			submissionRows, err := h.db.Query(`
 				SELECT 
 					cs.id, cs.user_id, cs.post_id, cs.created_at,
-					u.username, u.profile_image
+					u.username, u.profile_image, cpl.place, cpl.points
 				FROM challenge_submissions cs
 				JOIN users u ON cs.user_id = u.id
+				LEFT JOIN challenge_placements cpl ON cpl.challenge_id = cs.challenge_id AND cpl.user_id = cs.user_id
 				WHERE cs.challenge_id = ?
 				ORDER BY cs.created_at DESC
 			`, challenge.ID)
@@ -471,7 +454,7 @@ func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
 					var submission models.ChallengeSubmission
 					err := submissionRows.Scan(
 						&submission.ID, &submission.UserID, &submission.PostID, &submission.CreatedAt,
-						&submission.Username, &submission.UserProfileImage,
+						&submission.Username, &submission.UserProfileImage, &submission.Place, &submission.PointsAwarded,
 					)
 					if err != nil {
 						log.Printf("Error scanning submission: %v", err)
@@ -489,6 +472,9 @@ func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
 		if challenge.Status == "completed" {
 			// Keep as completed - everyone can see who completed it
 			challenge.Status = "completed"
+		} else if challenge.Status == "pending_verification" {
+			// Keep as pending - nobody can pick it while it's being verified
+			challenge.Status = "pending_verification"
 		} else if challenge.ChallengeType == "open" {
 			// Open challenges are always available
 			challenge.Status = "available"
@@ -503,11 +489,27 @@ func (h *Handler) GetChallenges(w http.ResponseWriter, r *http.Request) {
 		challenges = append(challenges, challenge)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(challenges)
+	if err := h.attachTags(challenges); err != nil {
+		http.Error(w, "Failed to load challenge tags", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.attachPrerequisites(challenges); err != nil {
+		http.Error(w, "Failed to load challenge prerequisites", http.StatusInternalServerError)
+		return
+	}
+
+	challenges, err = h.applyLocks(user.ID, challenges)
+	if err != nil {
+		http.Error(w, "Failed to check challenge unlocks", http.StatusInternalServerError)
+		return
+	}
+
+	writeChallenges(w, r, challenges)
 }
 
 func (h *Handler) GetChallenge(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
 	vars := mux.Vars(r)
 	challengeID, err := strconv.Atoi(vars["id"])
 	if err != nil {
@@ -517,19 +519,12 @@ func (h *Handler) GetChallenge(w http.ResponseWriter, r *http.Request) {
 
 	var challenge models.Challenge
 	err = h.db.QueryRow(`
-		SELECT 
-			c.id, c.title, c.description, c.image_url, c.points, c.assigned_to, c.status, 
-			c.completed_by, c.completed_post_id, c.completed_at, c.start_date, c.end_date, c.created_at,
+		SELECT `+challengeColumns+`,
 			u.username as completed_by_username
 		FROM challenges c
 		LEFT JOIN users u ON c.completed_by = u.id
-		WHERE c.id = ?
-	`, challengeID).Scan(
-		&challenge.ID, &challenge.Title, &challenge.Description,
-		&challenge.ImageURL, &challenge.Points, &challenge.AssignedTo,
-		&challenge.Status, &challenge.CompletedBy, &challenge.CompletedPostID,
-		&challenge.CompletedAt, &challenge.StartDate, &challenge.EndDate, &challenge.CreatedAt, &challenge.CompletedByUsername,
-	)
+		WHERE c.id = ? AND `+unlockedSQL+`
+	`, challengeID, user.ID).Scan(append(challengeScanFields(&challenge), &challenge.CompletedByUsername)...)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -540,6 +535,31 @@ func (h *Handler) GetChallenge(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	challenge.Tags, err = h.challengeTags(challenge.ID)
+	if err != nil {
+		http.Error(w, "Failed to load challenge tags", http.StatusInternalServerError)
+		return
+	}
+
+	challenge.PrerequisiteIDs, err = h.challengePrerequisites(challenge.ID)
+	if err != nil {
+		http.Error(w, "Failed to load challenge prerequisites", http.StatusInternalServerError)
+		return
+	}
+
+	progress, err := h.loadProgress(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to check challenge unlocks", http.StatusInternalServerError)
+		return
+	}
+	if lock := progress.lockFor(challenge); lock != nil {
+		if !challenge.ShowWhenLocked {
+			http.Error(w, "Challenge not found", http.StatusNotFound)
+			return
+		}
+		showLocked(&challenge, lock)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(challenge)
 }
\ No newline at end of file
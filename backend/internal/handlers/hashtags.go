@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"orlando-app/internal/models"
+	"orlando-app/internal/tags"
+	"strconv"
+)
+
+// setHashtags stores the #hashtags in a post's caption (commentID nil) or in
+// one of its comments. Tags the text still uses keep their original time so
+// editing a comment doesn't push them back up the trending list.
+func setHashtags(db querier, postID int, commentID *int, text string) error {
+	where := `post_id = ? AND comment_id IS NULL`
+	args := []interface{}{postID}
+	if commentID != nil {
+		where = `comment_id = ?`
+		args = []interface{}{*commentID}
+	}
+
+	existing := make(map[string]bool)
+	rows, err := db.Query(`SELECT tag FROM hashtags WHERE `+where, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[tag] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, tag := range tags.Hashtags(text) {
+		if existing[tag] {
+			delete(existing, tag)
+			continue
+		}
+		_, err := db.Exec(`INSERT INTO hashtags (post_id, comment_id, tag) VALUES (?, ?, ?)`, postID, commentID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	for tag := range existing {
+		if _, err := db.Exec(`DELETE FROM hashtags WHERE `+where+` AND tag = ?`, append(args, tag)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// liveHashtagSQL is true for hashtags (aliased as ht) in a caption or a
+// comment that is still showing; comments are joined as htc
+func (h *Handler) liveHashtagSQL() string {
+	return `(ht.comment_id IS NULL OR ` + h.visibleCommentSQL("htc") + `)`
+}
+
+// hashtagFilterSQL is true for posts (aliased as p) that use the tag in their
+// caption or in one of their comments
+func (h *Handler) hashtagFilterSQL() string {
+	return `EXISTS (SELECT 1 FROM hashtags ht LEFT JOIN comments htc ON ht.comment_id = htc.id
+		WHERE ht.post_id = p.id AND ht.tag = ? AND ` + h.liveHashtagSQL() + `)`
+}
+
+// GetTrendingTags lists the hashtags used most over the last
+// TrendingWindowHours, or the number of hours given as hours (up to a week).
+// Each tag carries how often it was used and on how many posts.
+func (h *Handler) GetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	hours := h.cfg.TrendingWindowHours
+	if value := r.URL.Query().Get("hours"); value != "" {
+		hoursNum, err := strconv.Atoi(value)
+		if err != nil || hoursNum <= 0 || hoursNum > 168 {
+			http.Error(w, "hours must be between 1 and 168", http.StatusBadRequest)
+			return
+		}
+		hours = hoursNum
+	}
+
+	limit := 10
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if limitNum, err := strconv.Atoi(l); err == nil && limitNum > 0 && limitNum <= 50 {
+			limit = limitNum
+		}
+	}
+
+	rows, err := h.db.Query(`
+		SELECT ht.tag, COUNT(*) AS uses, COUNT(DISTINCT ht.post_id) AS posts
+		FROM hashtags ht
+		JOIN posts p ON ht.post_id = p.id
+		LEFT JOIN comments htc ON ht.comment_id = htc.id
+		WHERE ht.created_at >= datetime(CURRENT_TIMESTAMP, ?) AND NOT `+h.hiddenSQL(postReports, "p")+` AND `+h.liveHashtagSQL()+`
+		GROUP BY ht.tag
+		ORDER BY uses DESC, posts DESC, ht.tag
+		LIMIT ?
+	`, fmt.Sprintf("-%d hours", hours), limit)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	trending := []models.TrendingTag{}
+	for rows.Next() {
+		var tag models.TrendingTag
+		if err := rows.Scan(&tag.Tag, &tag.Uses, &tag.Posts); err != nil {
+			http.Error(w, "Failed to scan tag", http.StatusInternalServerError)
+			return
+		}
+		trending = append(trending, tag)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trending)
+}
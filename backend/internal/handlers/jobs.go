@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"log"
+	"time"
+)
+
+// RunScheduler runs the background lifecycle jobs every interval. It blocks,
+// so callers start it in its own goroutine.
+func (h *Handler) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		h.runJobs()
+		<-ticker.C
+	}
+}
+
+func (h *Handler) runJobs() {
+	jobs := []struct {
+		name string
+		run  func() error
+	}{
+		{"expire closed claims", h.expireClosedClaims},
+		{"release timed out claims", h.releaseTimedOutClaims},
+		{"purge unlock attempts", h.purgeUnlockAttempts},
+		{"close challenge votes", h.closeVotes},
+		{"resolve overdue verifications", h.resolveOverdueVerifications},
+		{"refresh post scores", h.refreshPostScores},
+	}
+
+	for _, job := range jobs {
+		if err := job.run(); err != nil {
+			log.Printf("Scheduled job %q failed: %v", job.name, err)
+		}
+	}
+}
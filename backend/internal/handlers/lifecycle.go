@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"orlando-app/internal/models"
+	"time"
+)
+
+// lifecyclePhase identifies which step of a challenge's lifecycle is being
+// checked against its start_date/end_date window
+type lifecyclePhase int
+
+const (
+	// phasePick requires the window to be open right now
+	phasePick lifecyclePhase = iota
+	// phaseComplete additionally allows the grace period after end_date
+	phaseComplete
+	// phaseAward checks when the winning submission was made, not when the admin awards it
+	phaseAward
+)
+
+var (
+	errChallengeNotStarted = errors.New("Challenge has not started yet")
+	errChallengeEnded      = errors.New("Challenge has ended")
+)
+
+// activeWindowSQL limits a query on challenges aliased as "c" to challenges
+// that can be picked right now
+const activeWindowSQL = `(c.start_date IS NULL OR c.start_date <= CURRENT_TIMESTAMP)
+		AND (c.end_date IS NULL OR c.end_date >= CURRENT_TIMESTAMP)`
+
+func (h *Handler) gracePeriod() time.Duration {
+	return time.Duration(h.cfg.ChallengeGraceMinutes) * time.Minute
+}
+
+// graceWindowSQL matches challenges aliased as "c" whose end_date plus the
+// grace period has not passed yet
+func (h *Handler) graceWindowSQL() string {
+	return fmt.Sprintf(`(c.end_date IS NULL OR datetime(c.end_date, '+%d minutes') >= CURRENT_TIMESTAMP)`, h.cfg.ChallengeGraceMinutes)
+}
+
+// checkWindow enforces the challenge window for the given phase. at is the
+// moment being judged: now for picks and completions, the submission time for awards.
+func (h *Handler) checkWindow(challenge models.Challenge, phase lifecyclePhase, at time.Time) error {
+	if challenge.StartDate != nil && at.Before(*challenge.StartDate) {
+		return errChallengeNotStarted
+	}
+	if challenge.EndDate == nil {
+		return nil
+	}
+
+	deadline := *challenge.EndDate
+	if phase != phasePick {
+		deadline = deadline.Add(h.gracePeriod())
+	}
+	if at.After(deadline) {
+		return errChallengeEnded
+	}
+	return nil
+}
+
+// heldClaim is an exclusive assignment or an unsubmitted open challenge entry
+type heldClaim struct {
+	challengeID int
+	userID      int
+	title       string
+}
+
+// heldChallenges lists the in-progress exclusive claims and unsubmitted open
+// challenge entries a user currently holds
+func heldChallenges(tx *sql.Tx, userID int) ([]models.HeldChallenge, error) {
+	rows, err := tx.Query(`
+		SELECT id, title, challenge_type FROM challenges
+		WHERE assigned_to = ? AND status = 'in_progress'
+		UNION ALL
+		SELECT c.id, c.title, c.challenge_type
+		FROM challenge_submissions cs
+		JOIN challenges c ON cs.challenge_id = c.id
+		WHERE cs.user_id = ? AND cs.post_id = 0
+		ORDER BY id
+	`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var held []models.HeldChallenge
+	for rows.Next() {
+		var c models.HeldChallenge
+		if err := rows.Scan(&c.ID, &c.Title, &c.ChallengeType); err != nil {
+			return nil, err
+		}
+		held = append(held, c)
+	}
+	return held, rows.Err()
+}
+
+// releaseExclusiveSQL and releaseOpenSQL free a claim given (challenge_id, user_id)
+const (
+	releaseExclusiveSQL = `
+		UPDATE challenges SET assigned_to = NULL, status = 'available', claim_expires_at = NULL
+		WHERE id = ? AND assigned_to = ? AND status = 'in_progress'`
+	releaseOpenSQL = `
+		DELETE FROM challenge_submissions
+		WHERE challenge_id = ? AND user_id = ? AND post_id = 0`
+)
+
+// queryClaims runs a query selecting (challenge_id, user_id, title) rows
+func (h *Handler) queryClaims(query string, args ...interface{}) ([]heldClaim, error) {
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claims []heldClaim
+	for rows.Next() {
+		var claim heldClaim
+		if err := rows.Scan(&claim.challengeID, &claim.userID, &claim.title); err != nil {
+			return nil, err
+		}
+		claims = append(claims, claim)
+	}
+	return claims, rows.Err()
+}
+
+// releaseClaim frees a claim and tells the holder why. The release statement
+// re-checks the claim, so a claim completed in the meantime is left alone.
+func (h *Handler) releaseClaim(claim heldClaim, releaseSQL, kind, message string) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(releaseSQL, claim.challengeID, claim.userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected == 0 {
+		return err
+	}
+
+	challengeID := claim.challengeID
+	if err := notify(tx, claim.userID, kind, message, &challengeID, nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Released claim on challenge %d held by user %d (%s)", claim.challengeID, claim.userID, kind)
+	return nil
+}
+
+// expireClosedClaims releases exclusive claims and drops unsubmitted open
+// challenge entries once the challenge window and grace period have passed,
+// notifying each holder that their claim expired. Failures are logged per
+// claim, like releaseTimedOutClaims.
+func (h *Handler) expireClosedClaims() error {
+	modifier := fmt.Sprintf("+%d minutes", h.cfg.ChallengeGraceMinutes)
+
+	exclusive, err := h.queryClaims(`
+		SELECT id, assigned_to, title FROM challenges
+		WHERE challenge_type = 'exclusive' AND status = 'in_progress' AND assigned_to IS NOT NULL
+		AND end_date IS NOT NULL AND datetime(end_date, ?) < CURRENT_TIMESTAMP
+	`, modifier)
+	if err != nil {
+		return err
+	}
+	for _, claim := range exclusive {
+		message := fmt.Sprintf("Your claim on \"%s\" expired because the challenge window closed", claim.title)
+		if err := h.releaseClaim(claim, releaseExclusiveSQL, "claim_expired", message); err != nil {
+			log.Printf("Failed to release claim on challenge %d held by user %d: %v", claim.challengeID, claim.userID, err)
+		}
+	}
+
+	open, err := h.queryClaims(`
+		SELECT cs.challenge_id, cs.user_id, c.title
+		FROM challenge_submissions cs
+		JOIN challenges c ON cs.challenge_id = c.id
+		WHERE cs.post_id = 0
+		AND c.end_date IS NOT NULL AND datetime(c.end_date, ?) < CURRENT_TIMESTAMP
+	`, modifier)
+	if err != nil {
+		return err
+	}
+	for _, claim := range open {
+		message := fmt.Sprintf("Your entry in \"%s\" expired without a submission because the challenge window closed", claim.title)
+		if err := h.releaseClaim(claim, releaseOpenSQL, "claim_expired", message); err != nil {
+			log.Printf("Failed to release claim on challenge %d held by user %d: %v", claim.challengeID, claim.userID, err)
+		}
+	}
+
+	return nil
+}
+
+// releaseTimedOutClaims returns exclusive challenges whose claim TTL ran out
+// to the available pool. A claim that fails to release is logged and
+// retried on the next run rather than holding up the rest.
+func (h *Handler) releaseTimedOutClaims() error {
+	claims, err := h.queryClaims(`
+		SELECT id, assigned_to, title FROM challenges
+		WHERE challenge_type = 'exclusive' AND status = 'in_progress' AND assigned_to IS NOT NULL
+		AND claim_expires_at IS NOT NULL AND claim_expires_at < CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claims {
+		message := fmt.Sprintf("Your claim on \"%s\" timed out and the challenge is available to everyone again", claim.title)
+		if err := h.releaseClaim(claim, releaseExclusiveSQL, "claim_timeout", message); err != nil {
+			log.Printf("Failed to release claim on challenge %d held by user %d: %v", claim.challengeID, claim.userID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"orlando-app/internal/exif"
+	"orlando-app/internal/geo"
+	"orlando-app/internal/models"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	locationVerified   = "verified"
+	locationUnverified = "unverified"
+	locationOutside    = "outside"
+)
+
+// saveMedia writes an uploaded file to path. Photos are buffered so their GPS
+// position can be read before the EXIF block is stripped from the stored copy;
+// a photo that can't be stripped is refused with exif.ErrMalformed.
+func saveMedia(path string, src io.Reader, mediaType string) (*exif.Coordinates, error) {
+	if mediaType != "photo" {
+		dst, err := os.Create(path)
+		if err != nil {
+			return nil, err
+		}
+		defer dst.Close()
+
+		_, err = io.Copy(dst, src)
+		return nil, err
+	}
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, err
+	}
+
+	stripped, err := exif.Strip(data)
+	if err != nil {
+		return nil, err
+	}
+
+	coords := exif.ReadGPS(data)
+	if err := os.WriteFile(path, stripped, 0644); err != nil {
+		return nil, err
+	}
+	return coords, nil
+}
+
+// locationStatus compares photo coordinates against the challenge geofence.
+// It returns nil when the challenge has no geofence to check against.
+func locationStatus(challenge models.Challenge, coords *exif.Coordinates) *string {
+	hasCircle := challenge.GeofenceLat != nil && challenge.GeofenceLon != nil && challenge.GeofenceRadius != nil
+	hasPark := challenge.GeofencePark != nil && *challenge.GeofencePark != ""
+	if !hasCircle && !hasPark {
+		return nil
+	}
+
+	status := locationUnverified
+	if coords != nil {
+		point := geo.Point{Lat: coords.Lat, Lon: coords.Lon}
+		status = locationOutside
+		if hasCircle {
+			center := geo.Point{Lat: *challenge.GeofenceLat, Lon: *challenge.GeofenceLon}
+			if geo.DistanceMeters(point, center) <= *challenge.GeofenceRadius {
+				status = locationVerified
+			}
+		}
+		if hasPark && geo.InPark(point, *challenge.GeofencePark) {
+			status = locationVerified
+		}
+	}
+	return &status
+}
+
+// geofenceFromForm reads the optional geofence fields of a multipart challenge form
+func geofenceFromForm(r *http.Request, req *models.CreateChallengeRequest) error {
+	fields := []struct {
+		name string
+		dst  **float64
+	}{
+		{"geofence_lat", &req.GeofenceLat},
+		{"geofence_lon", &req.GeofenceLon},
+		{"geofence_radius_m", &req.GeofenceRadius},
+	}
+	for _, field := range fields {
+		value := r.FormValue(field.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid %s value", field.name)
+		}
+		*field.dst = &parsed
+	}
+
+	if park := r.FormValue("geofence_park"); park != "" {
+		req.GeofencePark = &park
+	}
+	return nil
+}
+
+// validateGeofence checks that a circle geofence is complete and the park is
+// known, normalizing the park name in place
+func validateGeofence(req *models.CreateChallengeRequest) error {
+	circleFields := 0
+	for _, value := range []*float64{req.GeofenceLat, req.GeofenceLon, req.GeofenceRadius} {
+		if value != nil {
+			circleFields++
+		}
+	}
+	if circleFields != 0 && circleFields != 3 {
+		return fmt.Errorf("geofence_lat, geofence_lon and geofence_radius_m must be set together")
+	}
+	if circleFields == 3 {
+		if *req.GeofenceLat < -90 || *req.GeofenceLat > 90 || *req.GeofenceLon < -180 || *req.GeofenceLon > 180 {
+			return fmt.Errorf("Geofence coordinates are out of range")
+		}
+		if *req.GeofenceRadius <= 0 {
+			return fmt.Errorf("geofence_radius_m must be positive")
+		}
+	}
+
+	if req.GeofencePark != nil {
+		if *req.GeofencePark == "" {
+			req.GeofencePark = nil
+			return nil
+		}
+		park := geo.NormalizeParkName(*req.GeofencePark)
+		if _, ok := geo.Parks[park]; !ok {
+			return fmt.Errorf("Unknown geofence_park. Valid parks: %s", strings.Join(geo.ParkNames(), ", "))
+		}
+		req.GeofencePark = &park
+	}
+	return nil
+}
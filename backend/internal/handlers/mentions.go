@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"database/sql"
+	"orlando-app/internal/mentions"
+	"orlando-app/internal/models"
+	"strings"
+)
+
+// setMentions stores the @username mentions in a post's caption (commentID
+// nil) or in one of its comments, replacing the ones stored before, and
+// notifies players who weren't already mentioned there. Names that don't
+// belong to a player stay plain text, and mentioning yourself is silent.
+func setMentions(db querier, author models.User, postID int, commentID *int, text, message string) error {
+	where := `post_id = ? AND comment_id IS NULL`
+	args := []interface{}{postID}
+	if commentID != nil {
+		where = `comment_id = ?`
+		args = []interface{}{*commentID}
+	}
+
+	notified := map[int]bool{author.ID: true}
+	rows, err := db.Query(`SELECT user_id FROM mentions WHERE `+where, args...)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return err
+		}
+		notified[userID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`DELETE FROM mentions WHERE `+where, args...); err != nil {
+		return err
+	}
+
+	for _, span := range mentions.Parse(text) {
+		var userID int
+		// Names match regardless of case, preferring an exact match
+		err := db.QueryRow(`
+			SELECT id FROM users WHERE username = ? COLLATE NOCASE
+			ORDER BY username = ? DESC LIMIT 1
+		`, span.Username, span.Username).Scan(&userID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = db.Exec(`
+			INSERT INTO mentions (post_id, comment_id, user_id, start_pos, end_pos) VALUES (?, ?, ?, ?, ?)
+		`, postID, commentID, userID, span.Start, span.End)
+		if err != nil {
+			return err
+		}
+
+		if !notified[userID] {
+			notified[userID] = true
+			if err := notify(db, userID, "mention", message, nil, &postID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadMentions loads the mentions keyed by column (post_id for captions,
+// comment_id for comments) for the given IDs, in text order
+func loadMentions(db querier, column string, ids []int) (map[int][]models.Mention, error) {
+	byID := make(map[int][]models.Mention)
+	if len(ids) == 0 {
+		return byID, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	filter := `m.` + column + ` IN (` + strings.Join(placeholders, ", ") + `)`
+	if column == "post_id" {
+		filter += ` AND m.comment_id IS NULL`
+	}
+
+	rows, err := db.Query(`
+		SELECT m.`+column+`, m.user_id, u.username, m.start_pos, m.end_pos
+		FROM mentions m
+		JOIN users u ON m.user_id = u.id
+		WHERE `+filter+`
+		ORDER BY m.start_pos
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var mention models.Mention
+		if err := rows.Scan(&id, &mention.UserID, &mention.Username, &mention.Start, &mention.End); err != nil {
+			return nil, err
+		}
+		byID[id] = append(byID[id], mention)
+	}
+	return byID, rows.Err()
+}
+
+// attachPostMentions fills in the caption mentions of every post in the list
+// with one query
+func (h *Handler) attachPostMentions(posts []models.Post) error {
+	ids := make([]int, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	byPost, err := loadMentions(h.db, "post_id", ids)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		posts[i].Mentions = byPost[posts[i].ID]
+	}
+	return nil
+}
+
+// attachCommentMentions fills in the mentions of every comment in the list
+// with one query. Blanked placeholders for deleted comments get none.
+func (h *Handler) attachCommentMentions(comments []models.Comment) error {
+	var ids []int
+	for i := range comments {
+		if !comments[i].Deleted {
+			ids = append(ids, comments[i].ID)
+		}
+	}
+	byComment, err := loadMentions(h.db, "comment_id", ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Mentions = byComment[comments[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	moderationApprove = "approve"
+	moderationReject  = "reject"
+	moderationRevoke  = "revoke"
+)
+
+// mediaHash fingerprints a stored upload so reused media can be spotted. It
+// returns nil when the file can't be read; the post is still accepted.
+func mediaHash(path string) *string {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("Failed to hash media %s: %v", path, err)
+		return nil
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		log.Printf("Failed to hash media %s: %v", path, err)
+		return nil
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	return &sum
+}
+
+// GetModerationQueue lists posts that need a moderator, oldest first: open
+// challenge submissions awaiting an award, completions awaiting verification,
+// photos taken outside the geofence, reused media and reported posts.
+// Filters: challenge_id, user_id, reason, and min_age_hours/max_age_hours.
+func (h *Handler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filters string
+	var args []interface{}
+	for _, filter := range []struct{ param, column string }{
+		{"challenge_id", "p.challenge_id"},
+		{"user_id", "p.user_id"},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid "+filter.param, http.StatusBadRequest)
+				return
+			}
+			filters += ` AND ` + filter.column + ` = ?`
+			args = append(args, id)
+		}
+	}
+	for _, filter := range []struct{ param, op string }{
+		{"min_age_hours", "<="},
+		{"max_age_hours", ">="},
+	} {
+		if value := query.Get(filter.param); value != "" {
+			hours, err := strconv.Atoi(value)
+			if err != nil || hours < 0 {
+				http.Error(w, "Invalid "+filter.param, http.StatusBadRequest)
+				return
+			}
+			filters += ` AND p.created_at ` + filter.op + ` datetime(CURRENT_TIMESTAMP, ?)`
+			args = append(args, fmt.Sprintf("-%d hours", hours))
+		}
+	}
+	reasonFilter := query.Get("reason")
+
+	rows, err := h.db.Query(`
+		SELECT * FROM (
+			SELECT p.id, p.challenge_id, c.title, c.challenge_type, p.user_id, u.username,
+				p.media_url, p.media_type, p.caption, p.location_status, p.moderation_status, p.created_at,
+				(c.challenge_type = 'open' AND c.status != 'completed'
+					AND EXISTS (SELECT 1 FROM challenge_submissions cs WHERE cs.post_id = p.id)) as pending_submission,
+				(c.status = 'pending_verification' AND c.completed_post_id = p.id) as pending_verification,
+				(SELECT group_concat(d.id) FROM posts d WHERE d.media_hash = p.media_hash AND d.id != p.id) as duplicates,
+				(SELECT COUNT(*) FROM post_reports pr WHERE pr.post_id = p.id AND pr.resolved_at IS NULL) as reports,
+				(SELECT group_concat(pr.reason, '; ') FROM post_reports pr WHERE pr.post_id = p.id AND pr.resolved_at IS NULL) as report_reasons
+			FROM posts p
+			JOIN challenges c ON p.challenge_id = c.id
+			JOIN users u ON p.user_id = u.id
+			WHERE p.revoked = FALSE`+filters+`
+		) q
+		WHERE pending_verification OR reports > 0 OR (moderation_status IS NULL AND
+			(pending_submission OR COALESCE(location_status, '') = 'outside' OR duplicates IS NOT NULL))
+		ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	queue := []models.ModerationItem{}
+	for rows.Next() {
+		var item models.ModerationItem
+		var pendingSubmission, pendingVerification bool
+		var duplicates, reportReasons *string
+		err := rows.Scan(&item.PostID, &item.ChallengeID, &item.ChallengeTitle, &item.ChallengeType, &item.UserID, &item.Username,
+			&item.MediaURL, &item.MediaType, &item.Caption, &item.LocationStatus, &item.ModerationStatus, &item.CreatedAt,
+			&pendingSubmission, &pendingVerification, &duplicates, &item.Reports, &reportReasons)
+		if err != nil {
+			http.Error(w, "Failed to scan moderation item", http.StatusInternalServerError)
+			return
+		}
+
+		item.Reasons = []string{}
+		if pendingSubmission {
+			item.Reasons = append(item.Reasons, "pending_submission")
+		}
+		if pendingVerification {
+			item.Reasons = append(item.Reasons, "pending_verification")
+		}
+		if item.LocationStatus != nil && *item.LocationStatus == locationOutside {
+			item.Reasons = append(item.Reasons, "location_outside")
+		}
+		if duplicates != nil {
+			item.Reasons = append(item.Reasons, "duplicate_media")
+			for _, field := range strings.Split(*duplicates, ",") {
+				if id, err := strconv.Atoi(field); err == nil {
+					item.DuplicatePostIDs = append(item.DuplicatePostIDs, id)
+				}
+			}
+		}
+		if item.Reports > 0 {
+			item.Reasons = append(item.Reasons, "reported")
+			item.ReportReasons = strings.Split(*reportReasons, "; ")
+		}
+
+		if reasonFilter != "" && !containsString(item.Reasons, reasonFilter) {
+			continue
+		}
+		queue = append(queue, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(queue)
+}
+
+// ModeratePost settles a post in the moderation queue. approve clears it
+// (verifying a pending completion), reject turns down a pending submission or
+// completion, and revoke takes the points back from a credited post. Rejecting
+// and revoking need a reason; the author is notified either way.
+func (h *Handler) ModeratePost(w http.ResponseWriter, r *http.Request) {
+	moderator := r.Context().Value(middleware.UserContextKey).(models.User)
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	switch req.Action {
+	case moderationApprove:
+	case moderationReject, moderationRevoke:
+		if req.Reason == "" {
+			http.Error(w, "Reason is required", http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "Action must be 'approve', 'reject' or 'revoke'", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var challenge models.Challenge
+	var authorID, completedPostID int
+	err = tx.QueryRow(`
+		SELECT c.id, c.title, c.points, c.challenge_type, c.status, COALESCE(c.completed_post_id, 0), p.user_id
+		FROM posts p
+		JOIN challenges c ON p.challenge_id = c.id
+		WHERE p.id = ? AND p.revoked = FALSE
+	`, postID).Scan(&challenge.ID, &challenge.Title, &challenge.Points, &challenge.ChallengeType, &challenge.Status,
+		&completedPostID, &authorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	pendingVerification := challenge.Status == "pending_verification" && completedPostID == postID
+	pending := pendingCompletion{
+		challengeID: challenge.ID, title: challenge.Title, points: challenge.Points, userID: authorID, postID: postID,
+	}
+
+	before, err := auditState(tx, challenge.ID, postID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// The verification helpers notify the author themselves
+	notifyAuthor := true
+	moderationStatus := "approved"
+	switch req.Action {
+	case moderationApprove:
+		if pendingVerification {
+			err = verifyCompletion(tx, pending)
+			notifyAuthor = false
+		}
+	case moderationReject:
+		moderationStatus = "rejected"
+		switch {
+		case pendingVerification:
+			err = rejectCompletion(tx, pending, req.Reason)
+			notifyAuthor = false
+		case challenge.ChallengeType == "open" && challenge.Status != "completed":
+			// The entry no longer counts, but the player may submit again
+			_, err = tx.Exec(`
+				UPDATE posts SET revoked = TRUE, revoke_reason = ?, revoked_at = CURRENT_TIMESTAMP WHERE id = ?
+			`, req.Reason, postID)
+			if err == nil {
+				_, err = tx.Exec(`UPDATE challenge_submissions SET post_id = 0 WHERE post_id = ?`, postID)
+			}
+		default:
+			http.Error(w, "Only pending submissions can be rejected, revoke credited posts instead", http.StatusConflict)
+			return
+		}
+	case moderationRevoke:
+		moderationStatus = "rejected"
+		err = revokePost(tx, challenge.ID, postID, req.Reason, false)
+	}
+	if err != nil {
+		http.Error(w, "Failed to moderate post", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = tx.Exec(`UPDATE posts SET moderation_status = ? WHERE id = ?`, moderationStatus, postID)
+	if err == nil {
+		_, err = tx.Exec(`UPDATE post_reports SET resolved_at = CURRENT_TIMESTAMP WHERE post_id = ? AND resolved_at IS NULL`, postID)
+	}
+	if err == nil {
+		_, err = tx.Exec(`
+			INSERT INTO moderation_actions (post_id, moderator_id, action, reason) VALUES (?, ?, ?, ?)
+		`, postID, moderator.ID, req.Action, nullIfEmpty(req.Reason))
+	}
+	if err == nil && notifyAuthor {
+		kind := map[string]string{moderationApprove: "post_approved", moderationReject: "post_rejected", moderationRevoke: "post_revoked"}[req.Action]
+		err = notify(tx, authorID, kind, moderationMessage(req.Action, challenge.Title, req.Reason), &challenge.ID, &postID)
+	}
+	if err == nil {
+		var after *auditSnapshot
+		if after, err = auditState(tx, challenge.ID, postID); err == nil {
+			err = recordAudit(tx, r, "post_"+req.Action, "post", postID, before, after)
+		}
+	}
+	if err != nil {
+		http.Error(w, "Failed to moderate post", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Post %d moderated by user %d: %s", postID, moderator.ID, req.Action)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Post moderated", "action": req.Action})
+}
+
+// moderationMessage tells the author what happened to their post
+func moderationMessage(action, title, reason string) string {
+	var message string
+	switch action {
+	case moderationApprove:
+		message = fmt.Sprintf("Your post for \"%s\" was approved by a moderator", title)
+	case moderationReject:
+		message = fmt.Sprintf("Your submission for \"%s\" was rejected, you can submit again", title)
+	default:
+		message = fmt.Sprintf("The points for your post on \"%s\" were revoked", title)
+	}
+	if reason != "" {
+		message += ": " + reason
+	}
+	return message
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
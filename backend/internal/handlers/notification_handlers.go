@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strings"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// notify queues a notification for a user. challengeID and postID are optional
+// references the client can use to link to the relevant screen.
+func notify(db execer, userID int, kind, message string, challengeID, postID *int) error {
+	_, err := db.Exec(`
+		INSERT INTO notifications (user_id, kind, message, challenge_id, post_id)
+		VALUES (?, ?, ?, ?, ?)
+	`, userID, kind, message, challengeID, postID)
+	return err
+}
+
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+
+	query := `
+		SELECT id, user_id, kind, message, challenge_id, post_id, read_at, created_at
+		FROM notifications
+		WHERE user_id = ?`
+	if r.URL.Query().Get("unread") == "true" {
+		query += ` AND read_at IS NULL`
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT 100`
+
+	rows, err := h.db.Query(query, user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &n.Message, &n.ChallengeID, &n.PostID, &n.ReadAt, &n.CreatedAt)
+		if err != nil {
+			http.Error(w, "Failed to scan notification", http.StatusInternalServerError)
+			return
+		}
+		notifications = append(notifications, n)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// MarkNotificationsRead marks the given notification IDs as read, or all of
+// the user's notifications when no IDs are sent
+func (h *Handler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	query := `UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = ? AND read_at IS NULL`
+	args := []interface{}{user.ID}
+	if len(req.IDs) > 0 {
+		query += ` AND id IN (?` + strings.Repeat(",?", len(req.IDs)-1) + `)`
+		for _, id := range req.IDs {
+			args = append(args, id)
+		}
+	}
+
+	if _, err := h.db.Exec(query, args...); err != nil {
+		http.Error(w, "Failed to update notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Notifications marked as read"})
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"orlando-app/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPlacements bounds how many ranked places an open challenge can pay out
+const maxPlacements = 10
+
+// validatePlacements rejects negative points and overly long placement lists
+func validatePlacements(req *models.CreateChallengeRequest) error {
+	if len(req.PlacementPoints) > maxPlacements {
+		return errors.New("An open challenge can have at most 10 placements")
+	}
+	for _, points := range req.PlacementPoints {
+		if points < 0 {
+			return errors.New("Invalid placement_points value")
+		}
+	}
+	if req.ParticipationPoints < 0 {
+		return errors.New("Invalid participation_points value")
+	}
+	return nil
+}
+
+// placementsFromForm reads placement_points ("5,3,1") and participation_points
+// from a multipart challenge form
+func placementsFromForm(r *http.Request, req *models.CreateChallengeRequest) error {
+	for _, field := range strings.Split(r.FormValue("placement_points"), ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		points, err := strconv.Atoi(field)
+		if err != nil {
+			return errors.New("Invalid placement_points value")
+		}
+		req.PlacementPoints = append(req.PlacementPoints, points)
+	}
+
+	if value := r.FormValue("participation_points"); value != "" {
+		points, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("Invalid participation_points value")
+		}
+		req.ParticipationPoints = points
+	}
+
+	return validatePlacements(req)
+}
+
+// effectivePlacements returns the points paid for each place. Challenges
+// without configured placements have a single winner worth the full points.
+func effectivePlacements(challenge models.Challenge) []int {
+	if len(challenge.PlacementPoints) == 0 {
+		return []int{challenge.Points}
+	}
+	return challenge.PlacementPoints
+}
+
+// openEntries are the valid entries of an open challenge: submissions with a
+// post that isn't revoked, made within the challenge window (plus grace)
+type openEntries struct {
+	// posts maps each entrant to their submitted post
+	posts map[int]int
+	// users lists the entrants in submission order, earliest first
+	users []int
+}
+
+func (h *Handler) openEntries(tx *sql.Tx, challenge models.Challenge) (*openEntries, error) {
+	rows, err := tx.Query(`
+		SELECT cs.user_id, cs.post_id, p.created_at FROM challenge_submissions cs
+		JOIN posts p ON cs.post_id = p.id
+		WHERE cs.challenge_id = ? AND cs.post_id > 0 AND p.revoked = FALSE
+		ORDER BY p.created_at, p.id
+	`, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := &openEntries{posts: make(map[int]int)}
+	for rows.Next() {
+		var userID, postID int
+		var submittedAt time.Time
+		if err := rows.Scan(&userID, &postID, &submittedAt); err != nil {
+			return nil, err
+		}
+		if h.checkWindow(challenge, phaseAward, submittedAt) != nil {
+			continue
+		}
+		entries.posts[userID] = postID
+		entries.users = append(entries.users, userID)
+	}
+	return entries, rows.Err()
+}
+
+// awardPlacements replaces the placements of an open challenge: the ranked
+// users take the placement points in order and every other entrant gets the
+// participation points. The first place is recorded as the challenge's winner.
+// ranked must be non-empty, fit the placements and only hold entrants.
+func awardPlacements(tx *sql.Tx, challenge models.Challenge, ranked []int, entries *openEntries) ([]models.ChallengePlacement, error) {
+	if _, err := tx.Exec(`DELETE FROM challenge_placements WHERE challenge_id = ?`, challenge.ID); err != nil {
+		return nil, err
+	}
+
+	placements := effectivePlacements(challenge)
+	placed := make(map[int]bool)
+	awarded := []models.ChallengePlacement{}
+	for i, userID := range ranked {
+		place := i + 1
+		placed[userID] = true
+		awarded = append(awarded, models.ChallengePlacement{
+			ChallengeID: challenge.ID, UserID: userID, PostID: entries.posts[userID], Place: &place, Points: placements[i],
+		})
+	}
+	if challenge.ParticipationPoints > 0 {
+		for _, userID := range entries.users {
+			if !placed[userID] {
+				awarded = append(awarded, models.ChallengePlacement{
+					ChallengeID: challenge.ID, UserID: userID, PostID: entries.posts[userID], Points: challenge.ParticipationPoints,
+				})
+			}
+		}
+	}
+
+	challengeID := challenge.ID
+	for i := range awarded {
+		placement := &awarded[i]
+		err := tx.QueryRow(`
+			INSERT INTO challenge_placements (challenge_id, user_id, post_id, place, points)
+			VALUES (?, ?, ?, ?, ?)
+			RETURNING created_at
+		`, placement.ChallengeID, placement.UserID, placement.PostID, placement.Place, placement.Points).Scan(&placement.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		message := fmt.Sprintf("You earned %d points for taking part in \"%s\"", placement.Points, challenge.Title)
+		if placement.Place != nil {
+			message = fmt.Sprintf("You placed #%d in \"%s\" and earned %d points", *placement.Place, challenge.Title, placement.Points)
+		}
+		postID := placement.PostID
+		if err := notify(tx, placement.UserID, "challenge_awarded", message, &challengeID, &postID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Awarding also ends any vote that was still running
+	_, err := tx.Exec(`
+		UPDATE challenges 
+		SET status = 'completed', completed_by = ?, completed_post_id = ?, completed_at = CURRENT_TIMESTAMP,
+			voting_closed_at = COALESCE(voting_closed_at, CURRENT_TIMESTAMP)
+		WHERE id = ?
+	`, ranked[0], entries.posts[ranked[0]], challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+	return awarded, nil
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"orlando-app/internal/models"
+	"orlando-app/internal/scoring"
+	"strconv"
+	"strings"
+)
+
+var (
+	errPrerequisiteSelf    = errors.New("A challenge cannot require itself")
+	errPrerequisiteUnknown = errors.New("Prerequisites must be existing challenges")
+	errPrerequisiteCycle   = errors.New("Prerequisites would create a dependency cycle")
+)
+
+// userProgress is what a user has done so far, used to decide which
+// challenges are unlocked for them
+type userProgress struct {
+	// completed holds exclusive challenges the user completed and open
+	// challenges they submitted an entry to
+	completed map[int]bool
+	// joined holds every open challenge the user has an entry in
+	joined map[int]bool
+	points int
+}
+
+// challengeLock says why a challenge is still locked for a user
+type challengeLock struct {
+	missing      []int
+	pointsNeeded int
+}
+
+func (h *Handler) loadProgress(userID int) (*userProgress, error) {
+	progress := &userProgress{completed: make(map[int]bool), joined: make(map[int]bool)}
+
+	rows, err := h.db.Query(`
+		SELECT c.id FROM challenges c
+		WHERE c.challenge_type = 'exclusive' AND c.status = 'completed' AND c.completed_by = ?
+		UNION
+		SELECT p.challenge_id FROM posts p
+		JOIN challenges c ON p.challenge_id = c.id
+		WHERE c.challenge_type = 'open' AND p.user_id = ? AND p.revoked = FALSE
+	`, userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		progress.completed[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	joinedRows, err := h.db.Query(`SELECT challenge_id FROM challenge_submissions WHERE user_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer joinedRows.Close()
+	for joinedRows.Next() {
+		var id int
+		if err := joinedRows.Scan(&id); err != nil {
+			return nil, err
+		}
+		progress.joined[id] = true
+	}
+	if err := joinedRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Same rules as the total_points shown on profiles
+	err = h.db.QueryRow(`
+		SELECT `+scoring.PointsSQL+`
+		FROM posts p
+		JOIN challenges c ON p.challenge_id = c.id
+		WHERE p.user_id = ? AND p.revoked = FALSE
+	`, userID).Scan(&progress.points)
+	if err != nil {
+		return nil, err
+	}
+
+	return progress, nil
+}
+
+// lockFor returns why the challenge is locked for the user, or nil. Challenges
+// the user already holds, joined or completed are never locked, even if their
+// prerequisites changed afterwards.
+func (p *userProgress) lockFor(challenge models.Challenge) *challengeLock {
+	if challenge.ChallengeType == "open" {
+		if p.joined[challenge.ID] {
+			return nil
+		}
+	} else if challenge.Status != "available" {
+		return nil
+	}
+
+	lock := &challengeLock{missing: []int{}}
+	for _, required := range challenge.PrerequisiteIDs {
+		if !p.completed[required] {
+			lock.missing = append(lock.missing, required)
+		}
+	}
+	if challenge.MinPoints != nil && p.points < *challenge.MinPoints {
+		lock.pointsNeeded = *challenge.MinPoints - p.points
+	}
+
+	if len(lock.missing) == 0 && lock.pointsNeeded == 0 {
+		return nil
+	}
+	return lock
+}
+
+// reason explains the lock to the user
+func (l *challengeLock) reason() string {
+	var reasons []string
+	if len(l.missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("complete %d more prerequisite challenge(s)", len(l.missing)))
+	}
+	if l.pointsNeeded > 0 {
+		reasons = append(reasons, fmt.Sprintf("earn %d more points", l.pointsNeeded))
+	}
+	return "Challenge is locked: " + strings.Join(reasons, " and ")
+}
+
+// showLocked turns a locked challenge into a teaser: the title stays visible
+// but what to do is hidden until it unlocks
+func showLocked(challenge *models.Challenge, lock *challengeLock) {
+	challenge.Locked = true
+	challenge.MissingPrerequisiteIDs = lock.missing
+	challenge.PointsNeeded = lock.pointsNeeded
+	challenge.Description = ""
+	challenge.ImageURL = nil
+	challenge.Submissions = nil
+}
+
+// applyLocks drops challenges the user hasn't unlocked yet, or shows them as
+// teasers when the challenge allows it. PrerequisiteIDs must already be attached.
+func (h *Handler) applyLocks(userID int, challenges []models.Challenge) ([]models.Challenge, error) {
+	progress, err := h.loadProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := challenges[:0]
+	for _, challenge := range challenges {
+		if lock := progress.lockFor(challenge); lock != nil {
+			if !challenge.ShowWhenLocked {
+				continue
+			}
+			showLocked(&challenge, lock)
+		}
+		visible = append(visible, challenge)
+	}
+	return visible, nil
+}
+
+// lockedChallenges lists the challenges still locked for the user, split into
+// those hidden from them and those shown as teasers
+func (h *Handler) lockedChallenges(userID int) (hidden []int, teasers []int, err error) {
+	progress, err := h.loadProgress(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	edges, err := h.prerequisiteMap()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := h.db.Query(`
+		SELECT c.id, c.challenge_type, c.status, c.min_points, COALESCE(c.show_when_locked, FALSE)
+		FROM challenges c
+		WHERE c.min_points IS NOT NULL OR EXISTS (SELECT 1 FROM challenge_prerequisites cp WHERE cp.challenge_id = c.id)
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var challenge models.Challenge
+		if err := rows.Scan(&challenge.ID, &challenge.ChallengeType, &challenge.Status, &challenge.MinPoints, &challenge.ShowWhenLocked); err != nil {
+			return nil, nil, err
+		}
+		challenge.PrerequisiteIDs = edges[challenge.ID]
+		if progress.lockFor(challenge) == nil {
+			continue
+		}
+		if challenge.ShowWhenLocked {
+			teasers = append(teasers, challenge.ID)
+		} else {
+			hidden = append(hidden, challenge.ID)
+		}
+	}
+	return hidden, teasers, rows.Err()
+}
+
+// prerequisiteMap loads every prerequisite edge as challenge -> required challenges
+func (h *Handler) prerequisiteMap() (map[int][]int, error) {
+	rows, err := h.db.Query(`SELECT challenge_id, required_challenge_id FROM challenge_prerequisites ORDER BY required_challenge_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := make(map[int][]int)
+	for rows.Next() {
+		var challengeID, requiredID int
+		if err := rows.Scan(&challengeID, &requiredID); err != nil {
+			return nil, err
+		}
+		edges[challengeID] = append(edges[challengeID], requiredID)
+	}
+	return edges, rows.Err()
+}
+
+// attachPrerequisites fills in the prerequisite IDs of every challenge in the list
+func (h *Handler) attachPrerequisites(challenges []models.Challenge) error {
+	edges, err := h.prerequisiteMap()
+	if err != nil {
+		return err
+	}
+	for i := range challenges {
+		challenges[i].PrerequisiteIDs = edges[challenges[i].ID]
+		if challenges[i].PrerequisiteIDs == nil {
+			challenges[i].PrerequisiteIDs = []int{}
+		}
+	}
+	return nil
+}
+
+// challengePrerequisites loads the prerequisite IDs of a single challenge
+func (h *Handler) challengePrerequisites(challengeID int) ([]int, error) {
+	rows, err := h.db.Query(`
+		SELECT required_challenge_id FROM challenge_prerequisites
+		WHERE challenge_id = ? ORDER BY required_challenge_id
+	`, challengeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	required := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		required = append(required, id)
+	}
+	return required, rows.Err()
+}
+
+// setChallengePrerequisites replaces the prerequisites of a challenge
+func setChallengePrerequisites(db execer, challengeID int, required []int) error {
+	if _, err := db.Exec(`DELETE FROM challenge_prerequisites WHERE challenge_id = ?`, challengeID); err != nil {
+		return err
+	}
+	for _, requiredID := range required {
+		_, err := db.Exec(`
+			INSERT INTO challenge_prerequisites (challenge_id, required_challenge_id) VALUES (?, ?)
+		`, challengeID, requiredID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePrerequisites de-duplicates the requested prerequisites and rejects
+// unknown challenges and dependency cycles. challengeID is 0 for a challenge
+// that doesn't exist yet, which nothing can depend on.
+func (h *Handler) validatePrerequisites(challengeID int, required []int) ([]int, error) {
+	seen := make(map[int]bool)
+	unique := []int{}
+	for _, id := range required {
+		if id == challengeID {
+			return nil, errPrerequisiteSelf
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) == 0 {
+		return unique, nil
+	}
+
+	args := make([]interface{}, len(unique))
+	for i, id := range unique {
+		args[i] = id
+	}
+	var found int
+	err := h.db.QueryRow(`
+		SELECT COUNT(*) FROM challenges WHERE id IN (?`+strings.Repeat(",?", len(unique)-1)+`)
+	`, args...).Scan(&found)
+	if err != nil {
+		return nil, err
+	}
+	if found != len(unique) {
+		return nil, errPrerequisiteUnknown
+	}
+
+	if challengeID == 0 {
+		return unique, nil
+	}
+
+	edges, err := h.prerequisiteMap()
+	if err != nil {
+		return nil, err
+	}
+	edges[challengeID] = unique
+
+	// Walk everything the challenge transitively requires; reaching the
+	// challenge itself means the new edges close a loop
+	visited := make(map[int]bool)
+	stack := append([]int{}, unique...)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == challengeID {
+			return nil, errPrerequisiteCycle
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		stack = append(stack, edges[id]...)
+	}
+
+	return unique, nil
+}
+
+// unlockRulesFromForm reads prerequisite_ids (repeated or comma-separated),
+// min_points and show_when_locked from a multipart challenge form
+func unlockRulesFromForm(r *http.Request, req *models.CreateChallengeRequest) error {
+	for _, list := range r.MultipartForm.Value["prerequisite_ids"] {
+		for _, field := range strings.Split(list, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			id, err := strconv.Atoi(field)
+			if err != nil {
+				return errors.New("Invalid prerequisite_ids value")
+			}
+			req.PrerequisiteIDs = append(req.PrerequisiteIDs, id)
+		}
+	}
+
+	if value := r.FormValue("min_points"); value != "" {
+		minPoints, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.New("Invalid min_points value")
+		}
+		req.MinPoints = &minPoints
+	}
+
+	if value := r.FormValue("show_when_locked"); value != "" {
+		show, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("Invalid show_when_locked value")
+		}
+		req.ShowWhenLocked = show
+	}
+
+	return validateMinPoints(req)
+}
+
+func validateMinPoints(req *models.CreateChallengeRequest) error {
+	if req.MinPoints != nil && *req.MinPoints < 0 {
+		return errors.New("Invalid min_points value")
+	}
+	if req.MinPoints != nil && *req.MinPoints == 0 {
+		req.MinPoints = nil
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"time"
+)
+
+// postScore is a post's weighted engagement and its age-decayed rank
+type postScore struct {
+	postID     int
+	engagement float64
+	rank       float64
+}
+
+// scorePost weighs reactions, comments and the challenge points a post is
+// worth into its engagement; rank divides that by (age in hours + 2) raised
+// to RankGravity so newer posts can climb past older ones
+func (h *Handler) scorePost(postID int, age time.Duration, points, reactionCount, commentCount int) postScore {
+	engagement := h.cfg.RankReactionWeight*float64(reactionCount) +
+		h.cfg.RankCommentWeight*float64(commentCount) +
+		h.cfg.RankPointsWeight*float64(points)
+	ageHours := math.Max(age.Hours(), 0)
+	return postScore{postID: postID, engagement: engagement, rank: engagement / math.Pow(ageHours+2, h.cfg.RankGravity)}
+}
+
+// upsertPostScoreSQL stores a post's score given (post_id, engagement, rank)
+const upsertPostScoreSQL = `
+	INSERT INTO post_scores (post_id, engagement, rank) VALUES (?, ?, ?)
+	ON CONFLICT (post_id) DO UPDATE SET engagement = excluded.engagement, rank = excluded.rank, updated_at = CURRENT_TIMESTAMP`
+
+// scoreNewPost scores a post as it's created so it doesn't sit at the
+// bottom of the ranked feed until the next refresh
+func (h *Handler) scoreNewPost(db execer, postID, points int) error {
+	score := h.scorePost(postID, 0, points, 0, 0)
+	_, err := db.Exec(upsertPostScoreSQL, score.postID, score.engagement, score.rank)
+	return err
+}
+
+// rankTolerance is how far, relative to the stored rank, a post's rank may
+// drift from aging alone before refreshPostScores rewrites it. Old posts
+// decay slowly enough that most of them are left alone on each run.
+const rankTolerance = 0.001
+
+// refreshPostScores recomputes the scores behind the ranked feed so requests
+// only have to read them. Only scores that changed are written, and posts
+// that are hidden or gone drop out.
+func (h *Handler) refreshPostScores() error {
+	stored := make(map[int]postScore)
+	rows, err := h.db.Query(`SELECT post_id, engagement, rank FROM post_scores`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var score postScore
+		if err := rows.Scan(&score.postID, &score.engagement, &score.rank); err != nil {
+			rows.Close()
+			return err
+		}
+		stored[score.postID] = score
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = h.db.Query(`
+		SELECT p.id, p.created_at,
+			CASE WHEN COALESCE(p.revoked, FALSE) THEN 0 ELSE c.points END,
+			(SELECT COUNT(*) FROM reactions rx WHERE rx.post_id = p.id),
+			(SELECT COUNT(*) FROM comments cm WHERE cm.post_id = p.id AND ` + h.visibleCommentSQL("cm") + `)
+		FROM posts p
+		JOIN challenges c ON p.challenge_id = c.id
+		WHERE NOT ` + h.hiddenSQL(postReports, "p"))
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var changed []postScore
+	for rows.Next() {
+		var postID, points, reactionCount, commentCount int
+		var createdAt time.Time
+		if err := rows.Scan(&postID, &createdAt, &points, &reactionCount, &commentCount); err != nil {
+			rows.Close()
+			return err
+		}
+		score := h.scorePost(postID, now.Sub(createdAt), points, reactionCount, commentCount)
+
+		old, ok := stored[postID]
+		delete(stored, postID)
+		if ok && old.engagement == score.engagement && math.Abs(old.rank-score.rank) <= rankTolerance*math.Abs(old.rank) {
+			continue
+		}
+		changed = append(changed, score)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// What's left in stored is hidden or deleted
+	if len(changed) == 0 && len(stored) == 0 {
+		return nil
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, score := range changed {
+		if _, err := tx.Exec(upsertPostScoreSQL, score.postID, score.engagement, score.rank); err != nil {
+			return err
+		}
+	}
+	for postID := range stored {
+		if _, err := tx.Exec(`DELETE FROM post_scores WHERE post_id = ?`, postID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// startOfToday is midnight in the trip's timezone, in the UTC format posts
+// are stored with
+func (h *Handler) startOfToday() string {
+	location, err := time.LoadLocation(h.cfg.TripTimezone)
+	if err != nil {
+		log.Printf("Invalid TRIP_TIMEZONE %q, using UTC: %v", h.cfg.TripTimezone, err)
+		location = time.UTC
+	}
+	now := time.Now().In(location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	return midnight.UTC().Format("2006-01-02 15:04:05")
+}
+
+// GetTopPosts lists the most engaged-with posts of period=today (the
+// default, in the trip's timezone) or period=trip, paged like the feed and
+// taking the same filters
+func (h *Handler) GetTopPosts(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Query().Get("period") {
+	case "", "today":
+		h.writeFeed(w, r, mostEngaged, ` AND p.created_at >= ?`, h.startOfToday())
+	case "trip":
+		h.writeFeed(w, r, mostEngaged, "")
+	default:
+		http.Error(w, "period must be 'today' or 'trip'", http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// reactions are the reactions players can leave on a post, in display order,
+// with the emoji the app shows for them
+var reactions = []struct{ name, emoji string }{
+	{"heart", "❤️"},
+	{"laugh", "😂"},
+	{"fire", "🔥"},
+	{"love", "😍"},
+	{"clap", "👏"},
+	{"wow", "😮"},
+}
+
+// defaultReaction is what a like from /posts/{id}/like leaves
+const defaultReaction = "heart"
+
+// reactionName resolves a reaction given by name or by its emoji, returning
+// "" for anything else
+func reactionName(value string) string {
+	value = strings.TrimSpace(value)
+	for _, reaction := range reactions {
+		if strings.EqualFold(value, reaction.name) || value == reaction.emoji {
+			return reaction.name
+		}
+	}
+	return ""
+}
+
+// attachReactions fills in the per-reaction counts of every post in the list
+// with one query
+func (h *Handler) attachReactions(posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(posts))
+	args := make([]interface{}, len(posts))
+	for i := range posts {
+		placeholders[i] = "?"
+		args[i] = posts[i].ID
+	}
+
+	rows, err := h.db.Query(`
+		SELECT post_id, reaction, COUNT(*) FROM reactions
+		WHERE post_id IN (`+strings.Join(placeholders, ", ")+`)
+		GROUP BY post_id, reaction
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	byPost := make(map[int]map[string]int)
+	for rows.Next() {
+		var postID, count int
+		var reaction string
+		if err := rows.Scan(&postID, &reaction, &count); err != nil {
+			return err
+		}
+		if byPost[postID] == nil {
+			byPost[postID] = make(map[string]int)
+		}
+		byPost[postID][reaction] = count
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range posts {
+		posts[i].Reactions = byPost[posts[i].ID]
+	}
+	return nil
+}
+
+// SetReaction leaves a reaction on a post, given by name or emoji, replacing
+// the player's earlier one. Each player has one reaction per post.
+func (h *Handler) SetReaction(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reaction string `json:"reaction"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	reaction := reactionName(req.Reaction)
+	if reaction == "" {
+		names := make([]string, len(reactions))
+		for i := range reactions {
+			names[i] = reactions[i].name
+		}
+		http.Error(w, "Reaction must be one of: "+strings.Join(names, ", "), http.StatusBadRequest)
+		return
+	}
+
+	var exists int
+	err = h.db.QueryRow(`SELECT 1 FROM posts WHERE id = ? AND removed_at IS NULL`, postID).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = h.db.Exec(`
+		INSERT INTO reactions (user_id, post_id, reaction) VALUES (?, ?, ?)
+		ON CONFLICT (user_id, post_id) DO UPDATE SET reaction = excluded.reaction, created_at = CURRENT_TIMESTAMP
+	`, user.ID, postID, reaction)
+	if err != nil {
+		http.Error(w, "Failed to save reaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Reaction saved", "reaction": reaction})
+}
+
+// GetPostReactions pages through who reacted to a post, newest first, with
+// the count of each reaction. reaction=fire limits the list to one reaction.
+func (h *Handler) GetPostReactions(w http.ResponseWriter, r *http.Request) {
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	page := 1
+	limit := 20
+	if p := query.Get("page"); p != "" {
+		if pageNum, err := strconv.Atoi(p); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+	if l := query.Get("limit"); l != "" {
+		if limitNum, err := strconv.Atoi(l); err == nil && limitNum > 0 && limitNum <= 100 {
+			limit = limitNum
+		}
+	}
+
+	var filter string
+	args := []interface{}{postID}
+	if value := query.Get("reaction"); value != "" {
+		reaction := reactionName(value)
+		if reaction == "" {
+			http.Error(w, "Invalid reaction", http.StatusBadRequest)
+			return
+		}
+		filter = ` AND r.reaction = ?`
+		args = append(args, reaction)
+	}
+
+	var exists int
+	if err := h.db.QueryRow(`SELECT 1 FROM posts WHERE id = ?`, postID).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Post not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	list := models.PostReactions{Reactions: []models.PostReaction{}, Counts: map[string]int{}, Page: page, Limit: limit}
+	posts := []models.Post{{ID: postID}}
+	if err := h.attachReactions(posts); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	for reaction, count := range posts[0].Reactions {
+		list.Counts[reaction] = count
+	}
+
+	err = h.db.QueryRow(`SELECT COUNT(*) FROM reactions r WHERE r.post_id = ?`+filter, args...).Scan(&list.Total)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT r.user_id, u.username, u.profile_image, r.reaction, r.created_at
+		FROM reactions r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.post_id = ?`+filter+`
+		ORDER BY r.created_at IS NULL, r.created_at DESC, r.user_id
+		LIMIT ? OFFSET ?
+	`, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reaction models.PostReaction
+		err := rows.Scan(&reaction.UserID, &reaction.Username, &reaction.UserProfileImage, &reaction.Reaction,
+			&reaction.CreatedAt)
+		if err != nil {
+			http.Error(w, "Failed to scan reaction", http.StatusInternalServerError)
+			return
+		}
+		list.Reactions = append(list.Reactions, reaction)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
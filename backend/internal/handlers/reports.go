@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// reportReasons are the categories a player picks from when reporting content
+var reportReasons = []string{"spam", "inappropriate", "harassment", "cheating", "other"}
+
+// reportTarget describes a kind of content players can report
+type reportTarget struct {
+	name          string // post or comment
+	label         string
+	table         string // the content table
+	reportTable   string
+	reportColumn  string // the reports table's reference to the content
+	contentColumn string // the text shown to moderators
+	postColumn    string // the post the content belongs to
+	liveSQL       string // true while the content can still be reported
+}
+
+var (
+	postReports    = reportTarget{"post", "Post", "posts", "post_reports", "post_id", "caption", "id", "removed_at IS NULL"}
+	commentReports = reportTarget{"comment", "Comment", "comments", "comment_reports", "comment_id", "content", "post_id", "removed_at IS NULL AND deleted_at IS NULL"}
+)
+
+// hiddenSQL is true for content an admin removed or that has reached the
+// report threshold. alias is the alias of the target's content table.
+func (h *Handler) hiddenSQL(target reportTarget, alias string) string {
+	removed := alias + `.removed_at IS NOT NULL`
+	if h.cfg.ReportHideThreshold <= 0 {
+		return `(` + removed + `)`
+	}
+	return fmt.Sprintf(`(%s OR (SELECT COUNT(*) FROM %s hr WHERE hr.%s = %s.id AND hr.resolved_at IS NULL) >= %d)`,
+		removed, target.reportTable, target.reportColumn, alias, h.cfg.ReportHideThreshold)
+}
+
+// ReportPost lets a player report a post for moderators to look at
+func (h *Handler) ReportPost(w http.ResponseWriter, r *http.Request) {
+	h.report(w, r, postReports)
+}
+
+// ReportComment lets a player report a comment for moderators to look at
+func (h *Handler) ReportComment(w http.ResponseWriter, r *http.Request) {
+	h.report(w, r, commentReports)
+}
+
+// report files a report against a post or comment. Each player reports a
+// piece of content once: reporting it again while the report is open updates
+// the reason, and once a moderator has resolved it the report is closed.
+func (h *Handler) report(w http.ResponseWriter, r *http.Request, target reportTarget) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid "+target.name+" ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Reason = strings.ToLower(strings.TrimSpace(req.Reason))
+	req.Details = strings.TrimSpace(req.Details)
+	if !containsString(reportReasons, req.Reason) {
+		http.Error(w, "Reason must be one of: "+strings.Join(reportReasons, ", "), http.StatusBadRequest)
+		return
+	}
+	if req.Reason == "other" && req.Details == "" {
+		http.Error(w, "Details are required for reason 'other'", http.StatusBadRequest)
+		return
+	}
+
+	var authorID int
+	err = h.db.QueryRow(`SELECT user_id FROM `+target.table+` WHERE id = ? AND `+target.liveSQL, targetID).Scan(&authorID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, target.label+" not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if authorID == user.ID {
+		http.Error(w, "You cannot report your own "+target.name, http.StatusBadRequest)
+		return
+	}
+
+	var resolvedAt *time.Time
+	err = h.db.QueryRow(`
+		SELECT resolved_at FROM `+target.reportTable+` WHERE `+target.reportColumn+` = ? AND reporter_id = ?
+	`, targetID, user.ID).Scan(&resolvedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = h.db.Exec(`
+			INSERT INTO `+target.reportTable+` (`+target.reportColumn+`, reporter_id, reason, details) VALUES (?, ?, ?, ?)
+		`, targetID, user.ID, req.Reason, nullIfEmpty(req.Details))
+		if err != nil {
+			http.Error(w, "Failed to report "+target.name, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"message": target.label + " reported"})
+	case err != nil:
+		http.Error(w, "Database error", http.StatusInternalServerError)
+	case resolvedAt != nil:
+		http.Error(w, "You already reported this "+target.name+" and a moderator has reviewed it", http.StatusConflict)
+	default:
+		_, err = h.db.Exec(`
+			UPDATE `+target.reportTable+` SET reason = ?, details = ?
+			WHERE `+target.reportColumn+` = ? AND reporter_id = ?
+		`, req.Reason, nullIfEmpty(req.Details), targetID, user.ID)
+		if err != nil {
+			http.Error(w, "Failed to report "+target.name, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Report updated"})
+	}
+}
+
+// GetReports lists posts and comments with open reports, most reported
+// first. target_type=post or target_type=comment limits it to one kind.
+func (h *Handler) GetReports(w http.ResponseWriter, r *http.Request) {
+	var targets []reportTarget
+	switch r.URL.Query().Get("target_type") {
+	case "":
+		targets = []reportTarget{postReports, commentReports}
+	case "post":
+		targets = []reportTarget{postReports}
+	case "comment":
+		targets = []reportTarget{commentReports}
+	default:
+		http.Error(w, "target_type must be 'post' or 'comment'", http.StatusBadRequest)
+		return
+	}
+
+	reported := []*models.ReportedContent{}
+	for _, target := range targets {
+		rows, err := h.db.Query(`
+			SELECT x.id, x.` + target.postColumn + `, x.user_id, u.username, x.` + target.contentColumn + `, ` + h.hiddenSQL(target, "x") + `,
+				r.reason, r.details, r.created_at
+			FROM ` + target.reportTable + ` r
+			JOIN ` + target.table + ` x ON r.` + target.reportColumn + ` = x.id
+			JOIN users u ON x.user_id = u.id
+			WHERE r.resolved_at IS NULL
+			ORDER BY r.created_at, r.id
+		`)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+
+		byID := make(map[int]*models.ReportedContent)
+		for rows.Next() {
+			var item models.ReportedContent
+			var reason string
+			var details *string
+			var reportedAt time.Time
+			err := rows.Scan(&item.TargetID, &item.PostID, &item.AuthorID, &item.AuthorUsername, &item.Content, &item.Hidden,
+				&reason, &details, &reportedAt)
+			if err != nil {
+				rows.Close()
+				http.Error(w, "Failed to scan report", http.StatusInternalServerError)
+				return
+			}
+
+			entry, ok := byID[item.TargetID]
+			if !ok {
+				item.TargetType = target.name
+				item.Reasons = make(map[string]int)
+				item.FirstReportedAt = reportedAt
+				entry = &item
+				byID[item.TargetID] = entry
+				reported = append(reported, entry)
+			}
+			entry.Reports++
+			entry.Reasons[reason]++
+			if details != nil {
+				entry.Details = append(entry.Details, *details)
+			}
+			entry.LastReportedAt = reportedAt
+		}
+		rows.Close()
+	}
+
+	sort.SliceStable(reported, func(i, j int) bool {
+		if reported[i].Reports != reported[j].Reports {
+			return reported[i].Reports > reported[j].Reports
+		}
+		return reported[i].FirstReportedAt.Before(reported[j].FirstReportedAt)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reported)
+}
+
+// ResolvePostReports closes the open reports against a post
+func (h *Handler) ResolvePostReports(w http.ResponseWriter, r *http.Request) {
+	h.resolveReports(w, r, postReports)
+}
+
+// ResolveCommentReports closes the open reports against a comment
+func (h *Handler) ResolveCommentReports(w http.ResponseWriter, r *http.Request) {
+	h.resolveReports(w, r, commentReports)
+}
+
+// resolveReports closes the open reports against a post or comment with
+// {"action": "dismiss"} to keep it visible or {"action": "remove"} to take it
+// down for good. Removing a post only hides it; use the moderation endpoints
+// to take back its points.
+func (h *Handler) resolveReports(w http.ResponseWriter, r *http.Request, target reportTarget) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid "+target.name+" ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Action != "dismiss" && req.Action != "remove" {
+		http.Error(w, "Action must be 'dismiss' or 'remove'", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	var authorID, postID int
+	err = tx.QueryRow(`
+		SELECT user_id, `+target.postColumn+` FROM `+target.table+` WHERE id = ?
+	`, targetID).Scan(&authorID, &postID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, target.label+" not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	var before *auditSnapshot
+	if target == postReports {
+		if before, err = auditState(tx, 0, postID); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE `+target.reportTable+` SET resolved_at = CURRENT_TIMESTAMP
+		WHERE `+target.reportColumn+` = ? AND resolved_at IS NULL
+	`, targetID)
+	if err != nil {
+		http.Error(w, "Failed to resolve reports", http.StatusInternalServerError)
+		return
+	}
+	resolved, err := result.RowsAffected()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if resolved == 0 {
+		http.Error(w, "No open reports for this "+target.name, http.StatusNotFound)
+		return
+	}
+
+	if req.Action == "remove" {
+		_, err = tx.Exec(`UPDATE `+target.table+` SET removed_at = CURRENT_TIMESTAMP WHERE id = ?`, targetID)
+		if err == nil {
+			message := fmt.Sprintf("Your %s was removed by a moderator after it was reported", target.name)
+			err = notify(tx, authorID, target.name+"_removed", message, nil, &postID)
+		}
+		if err != nil {
+			http.Error(w, "Failed to remove "+target.name, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	var after *auditSnapshot
+	if target == postReports {
+		after, err = auditState(tx, 0, postID)
+	}
+	if err == nil {
+		err = recordAudit(tx, r, "report_"+req.Action, target.name, targetID, before, after)
+	}
+	if err != nil {
+		http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":  "Reports resolved",
+		"action":   req.Action,
+		"resolved": resolved,
+	})
+}
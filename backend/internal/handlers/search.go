@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"html"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// searchTypes are the kinds of rows /search can be limited to with type=
+var searchTypes = []string{"challenge", "post", "comment", "user"}
+
+// searchMatch turns what the player typed into an FTS5 query matching rows
+// that contain every word, each as a prefix so results show while typing.
+// Words are quoted so FTS5 operators in the input are taken literally.
+func searchMatch(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + word + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// idListSQL returns an IN list of placeholders for ids and their arguments
+func idListSQL(ids []int) (string, []interface{}) {
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	return `(` + strings.Join(placeholders, ", ") + `)`, args
+}
+
+// markStart and markEnd stand in for <mark> and </mark> in snippets until
+// the text around them has been escaped
+const (
+	markStart = "\x02"
+	markEnd   = "\x03"
+)
+
+// highlightSnippet escapes the user text in a snippet so it's safe to render
+// as HTML, then turns the match markers into <mark> tags. Marker characters
+// typed by players only ever become <mark> tags too.
+func highlightSnippet(snippet string) string {
+	return strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>").Replace(html.EscapeString(snippet))
+}
+
+// Search finds challenges, posts, comments and players matching q, best
+// match first, with an HTML-escaped snippet of each match where the matching
+// words are wrapped in <mark>. type= limits it to one kind. Challenges are
+// left out or shown as teasers the same way GetChallenges does, and hidden or
+// deleted content is left out.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	query := r.URL.Query()
+
+	match := searchMatch(query.Get("q"))
+	if match == "" {
+		http.Error(w, "Search query is required", http.StatusBadRequest)
+		return
+	}
+
+	page := 1
+	limit := 20
+	if p := query.Get("page"); p != "" {
+		if pageNum, err := strconv.Atoi(p); err == nil && pageNum > 0 {
+			page = pageNum
+		}
+	}
+	if l := query.Get("limit"); l != "" {
+		if limitNum, err := strconv.Atoi(l); err == nil && limitNum > 0 && limitNum <= 100 {
+			limit = limitNum
+		}
+	}
+
+	// The index can be left over from an FTS5 build this one can't read
+	var available bool
+	err := h.db.QueryRow(`
+		SELECT sqlite_compileoption_used('ENABLE_FTS5') AND EXISTS (SELECT 1 FROM sqlite_master WHERE name = 'search_index')
+	`).Scan(&available)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if !available {
+		http.Error(w, "Search is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Teasers only match on their title, since their description is secret
+	hidden, teasers, err := h.lockedChallenges(user.ID)
+	if err != nil {
+		http.Error(w, "Failed to check challenge unlocks", http.StatusInternalServerError)
+		return
+	}
+	hiddenList, hiddenArgs := idListSQL(hidden)
+	teaserList, teaserArgs := idListSQL(teasers)
+
+	// Comments also go when their post is hidden
+	filter := `search_index MATCH ? AND (
+			(si.kind = 'challenge' AND ` + unlockedSQL + `
+				AND (c.start_date IS NULL OR c.start_date <= CURRENT_TIMESTAMP)
+				AND c.id NOT IN ` + hiddenList + `
+				AND (c.id NOT IN ` + teaserList + ` OR si.rowid IN (SELECT rowid FROM search_index WHERE search_index MATCH ?)))
+			OR (si.kind = 'post' AND NOT ` + h.hiddenSQL(postReports, "p") + `)
+			OR (si.kind = 'comment' AND ` + h.visibleCommentSQL("cm") + ` AND NOT ` + h.hiddenSQL(postReports, "cp") + `)
+			OR si.kind = 'user'
+		)`
+	args := append([]interface{}{match, user.ID}, hiddenArgs...)
+	args = append(append(args, teaserArgs...), "title : ("+match+")")
+	if value := query.Get("type"); value != "" {
+		if !containsString(searchTypes, value) {
+			http.Error(w, "type must be one of: "+strings.Join(searchTypes, ", "), http.StatusBadRequest)
+			return
+		}
+		filter += ` AND si.kind = ?`
+		args = append(args, value)
+	}
+
+	joins := `
+		FROM search_index si
+		LEFT JOIN challenges c ON si.kind = 'challenge' AND c.id = si.ref_id
+		LEFT JOIN posts p ON si.kind = 'post' AND p.id = si.ref_id
+		LEFT JOIN comments cm ON si.kind = 'comment' AND cm.id = si.ref_id
+		LEFT JOIN posts cp ON cm.post_id = cp.id
+		LEFT JOIN users u ON u.id = CASE si.kind WHEN 'post' THEN p.user_id WHEN 'comment' THEN cm.user_id ELSE si.ref_id END
+	`
+
+	results := models.SearchResults{Results: []models.SearchResult{}, Page: page, Limit: limit}
+	if err := h.db.QueryRow(`SELECT COUNT(*) `+joins+` WHERE `+filter, args...).Scan(&results.Total); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	// Posts and comments are titled with their author; bm25 is lower for
+	// better matches and weighs a match in the title twice as much. Teaser
+	// snippets come from the title alone.
+	rows, err := h.db.Query(`
+		SELECT si.kind, si.ref_id, cm.post_id,
+			CASE si.kind WHEN 'challenge' THEN c.title ELSE u.username END,
+			CASE WHEN si.kind = 'challenge' AND c.id IN `+teaserList+`
+				THEN snippet(search_index, 2, ?, ?, '…', 12)
+				ELSE snippet(search_index, -1, ?, ?, '…', 12) END,
+			bm25(search_index, 0, 0, 2.0, 1.0)
+		`+joins+`
+		WHERE `+filter+`
+		ORDER BY bm25(search_index, 0, 0, 2.0, 1.0), si.rowid
+		LIMIT ? OFFSET ?
+	`, append(append(append(append([]interface{}{}, teaserArgs...), markStart, markEnd, markStart, markEnd), args...), limit, (page-1)*limit)...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.SearchResult
+		var postID sql.NullInt64
+		var title sql.NullString
+		var rank float64
+		if err := rows.Scan(&result.Type, &result.ID, &postID, &title, &result.Snippet, &rank); err != nil {
+			http.Error(w, "Failed to scan search result", http.StatusInternalServerError)
+			return
+		}
+		if postID.Valid {
+			id := int(postID.Int64)
+			result.PostID = &id
+		}
+		result.Title = title.String
+		result.Snippet = highlightSnippet(result.Snippet)
+		result.Score = -rank
+		results.Results = append(results.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strconv"
+	"strings"
+)
+
+const (
+	// unlockScopeUser reveals a hidden challenge only to the player who entered the code
+	unlockScopeUser = "user"
+	// unlockScopeGlobal reveals it to everyone once anyone enters the code
+	unlockScopeGlobal = "global"
+)
+
+// unlockedSQL limits a query on challenges aliased as "c" to challenges that
+// aren't hidden from the user. It takes the user ID as its only parameter.
+const unlockedSQL = `(COALESCE(c.hidden, FALSE) = FALSE OR c.unlocked_at IS NOT NULL
+		OR EXISTS (SELECT 1 FROM challenge_unlocks cu WHERE cu.challenge_id = c.id AND cu.user_id = ?))`
+
+// normalizeUnlockCode makes codes forgiving to type: "sw-42 x" matches "SW42X"
+func normalizeUnlockCode(code string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// validateSecret normalizes the hidden challenge settings of a request.
+// Visible challenges don't keep an unlock code.
+func validateSecret(req *models.CreateChallengeRequest) error {
+	if !req.Hidden {
+		req.UnlockCode = nil
+		req.UnlockScope = ""
+		return nil
+	}
+
+	if req.UnlockCode != nil {
+		code := normalizeUnlockCode(*req.UnlockCode)
+		req.UnlockCode = &code
+	}
+	if req.UnlockCode == nil || *req.UnlockCode == "" {
+		return errors.New("Hidden challenges need an unlock_code")
+	}
+
+	if req.UnlockScope == "" {
+		req.UnlockScope = unlockScopeUser
+	}
+	if req.UnlockScope != unlockScopeUser && req.UnlockScope != unlockScopeGlobal {
+		return errors.New("unlock_scope must be 'user' or 'global'")
+	}
+	return nil
+}
+
+// secretFromForm reads hidden, unlock_code and unlock_scope from a multipart
+// challenge form
+func secretFromForm(r *http.Request, req *models.CreateChallengeRequest) error {
+	if value := r.FormValue("hidden"); value != "" {
+		hidden, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("Invalid hidden value")
+		}
+		req.Hidden = hidden
+	}
+	if code := r.FormValue("unlock_code"); code != "" {
+		req.UnlockCode = &code
+	}
+	req.UnlockScope = r.FormValue("unlock_scope")
+	return validateSecret(req)
+}
+
+// UnlockChallenge reveals the hidden challenges matching a secret code. Failed
+// attempts are counted per user and too many within the window are refused.
+func (h *Handler) UnlockChallenge(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	code := normalizeUnlockCode(req.Code)
+	if code == "" {
+		http.Error(w, "Code is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	// The attempt is written as a failure before counting so concurrent
+	// guesses can't all slip under the limit; a matching code flips it below
+	result, err := tx.Exec(`INSERT INTO unlock_attempts (user_id, success) VALUES (?, FALSE)`, user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	attemptID, err := result.LastInsertId()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	window := fmt.Sprintf("-%d minutes", h.cfg.UnlockWindowMinutes)
+	var failed int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM unlock_attempts
+		WHERE user_id = ? AND success = FALSE AND created_at > datetime(CURRENT_TIMESTAMP, ?) AND id != ?
+	`, user.ID, window, attemptID).Scan(&failed)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if failed >= h.cfg.UnlockMaxAttempts {
+		w.Header().Set("Retry-After", strconv.Itoa(h.cfg.UnlockWindowMinutes*60))
+		http.Error(w, "Too many wrong codes, try again later", http.StatusTooManyRequests)
+		return
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, unlock_scope, unlocked_at IS NOT NULL FROM challenges
+		WHERE hidden = TRUE AND unlock_code = ?
+	`, code)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	type match struct {
+		id             int
+		scope          string
+		globallyOpened bool
+	}
+	var matches []match
+	for rows.Next() {
+		var m match
+		var scope *string
+		if err := rows.Scan(&m.id, &scope, &m.globallyOpened); err != nil {
+			rows.Close()
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		if scope != nil {
+			m.scope = *scope
+		}
+		matches = append(matches, m)
+	}
+	rows.Close()
+
+	if len(matches) == 0 {
+		if err := tx.Commit(); err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("User %d entered an invalid unlock code (%d recent failures)", user.ID, failed+1)
+		http.Error(w, "Invalid code", http.StatusNotFound)
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE unlock_attempts SET success = TRUE WHERE id = ?`, attemptID); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	for _, m := range matches {
+		_, err := tx.Exec(`
+			INSERT INTO challenge_unlocks (challenge_id, user_id) VALUES (?, ?)
+			ON CONFLICT (challenge_id, user_id) DO NOTHING
+		`, m.id, user.ID)
+		if err != nil {
+			http.Error(w, "Failed to unlock challenge", http.StatusInternalServerError)
+			return
+		}
+
+		if m.scope == unlockScopeGlobal && !m.globallyOpened {
+			_, err := tx.Exec(`UPDATE challenges SET unlocked_at = CURRENT_TIMESTAMP WHERE id = ? AND unlocked_at IS NULL`, m.id)
+			if err != nil {
+				http.Error(w, "Failed to unlock challenge", http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	unlocked := []models.Challenge{}
+	for _, m := range matches {
+		var challenge models.Challenge
+		err := h.db.QueryRow(`
+			SELECT `+challengeColumns+`
+			FROM challenges c WHERE c.id = ?
+		`, m.id).Scan(challengeScanFields(&challenge)...)
+		if err != nil {
+			http.Error(w, "Failed to fetch unlocked challenge", http.StatusInternalServerError)
+			return
+		}
+		unlocked = append(unlocked, challenge)
+		log.Printf("Hidden challenge %d unlocked by user %d", m.id, user.ID)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Challenge unlocked",
+		"challenges": unlocked,
+	})
+}
+
+// GetChallengeUnlocks lists who unlocked which hidden challenge, newest
+// first, optionally for a single challenge with ?challenge_id=
+func (h *Handler) GetChallengeUnlocks(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT cu.challenge_id, c.title, cu.user_id, u.username, cu.created_at
+		FROM challenge_unlocks cu
+		JOIN challenges c ON cu.challenge_id = c.id
+		JOIN users u ON cu.user_id = u.id`
+	var args []interface{}
+	if idStr := r.URL.Query().Get("challenge_id"); idStr != "" {
+		challengeID, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+			return
+		}
+		query += ` WHERE cu.challenge_id = ?`
+		args = append(args, challengeID)
+	}
+	query += ` ORDER BY cu.created_at DESC`
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	unlocks := []models.ChallengeUnlock{}
+	for rows.Next() {
+		var unlock models.ChallengeUnlock
+		err := rows.Scan(&unlock.ChallengeID, &unlock.ChallengeTitle, &unlock.UserID, &unlock.Username, &unlock.CreatedAt)
+		if err != nil {
+			http.Error(w, "Failed to scan unlock", http.StatusInternalServerError)
+			return
+		}
+		unlocks = append(unlocks, unlock)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(unlocks)
+}
+
+// purgeUnlockAttempts drops attempts that no longer count towards the limit
+func (h *Handler) purgeUnlockAttempts() error {
+	_, err := h.db.Exec(`
+		DELETE FROM unlock_attempts WHERE created_at < datetime(CURRENT_TIMESTAMP, ?)
+	`, fmt.Sprintf("-%d minutes", h.cfg.UnlockWindowMinutes))
+	return err
+}
+
+// nullIfEmpty stores an empty string as NULL
+func nullIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
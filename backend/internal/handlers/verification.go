@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// isModerator reports whether a user's single review settles a completion
+func isModerator(user models.User) bool {
+	return user.Role == "admin" || user.Role == "moderator"
+}
+
+// verificationDueSQL is the SQLite modifier for a new completion's review deadline
+func (h *Handler) verificationDueSQL() *string {
+	if h.cfg.VerificationTimeoutMinutes <= 0 {
+		return nil
+	}
+	modifier := fmt.Sprintf("+%d minutes", h.cfg.VerificationTimeoutMinutes)
+	return &modifier
+}
+
+// pendingCompletion is the completion currently awaiting review on a challenge
+type pendingCompletion struct {
+	challengeID int
+	title       string
+	points      int
+	userID      int
+	postID      int
+	escalated   bool
+}
+
+// verifyCompletion credits a pending completion
+func verifyCompletion(tx *sql.Tx, pending pendingCompletion) error {
+	_, err := tx.Exec(`
+		UPDATE challenges
+		SET status = 'completed', completed_at = CURRENT_TIMESTAMP, verification_due_at = NULL, verification_escalated = FALSE
+		WHERE id = ? AND status = 'pending_verification'
+	`, pending.challengeID)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Your completion of \"%s\" was verified: %d points earned", pending.title, pending.points)
+	return notify(tx, pending.userID, "completion_verified", message, &pending.challengeID, &pending.postID)
+}
+
+// rejectCompletion revokes the post and returns the challenge to the pool.
+// reason is passed on to the author when given.
+func rejectCompletion(tx *sql.Tx, pending pendingCompletion, reason string) error {
+	_, err := tx.Exec(`
+		UPDATE challenges
+		SET status = 'available', completed_by = NULL, completed_post_id = NULL, completed_at = NULL,
+			verification_due_at = NULL, verification_escalated = FALSE
+		WHERE id = ? AND status = 'pending_verification'
+	`, pending.challengeID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		UPDATE posts SET revoked = TRUE, revoke_reason = ?, revoked_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, nullIfEmpty(reason), pending.postID)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Your completion of \"%s\" was rejected by a moderator", pending.title)
+	if reason != "" {
+		message += ": " + reason
+	}
+	return notify(tx, pending.userID, "completion_rejected", message, &pending.challengeID, &pending.postID)
+}
+
+// escalateCompletion leaves a disputed or overdue completion for moderators
+func escalateCompletion(tx *sql.Tx, pending pendingCompletion) error {
+	_, err := tx.Exec(`
+		UPDATE challenges SET verification_escalated = TRUE, verification_due_at = NULL
+		WHERE id = ? AND status = 'pending_verification'
+	`, pending.challengeID)
+	if err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Your completion of \"%s\" was passed on to a moderator for review", pending.title)
+	return notify(tx, pending.userID, "completion_escalated", message, &pending.challengeID, &pending.postID)
+}
+
+// GetPendingVerifications lists completions the user can review: other
+// players' completions they haven't reviewed yet. Moderators also see
+// escalated ones.
+func (h *Handler) GetPendingVerifications(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+
+	query := `
+		SELECT c.id, c.title, c.points, p.id, p.user_id, u.username, p.media_url, p.media_type, p.caption,
+			(SELECT COUNT(*) FROM completion_reviews cr WHERE cr.post_id = p.id AND cr.approved = TRUE),
+			(SELECT COUNT(*) FROM completion_reviews cr WHERE cr.post_id = p.id AND cr.approved = FALSE),
+			COALESCE(c.verification_escalated, FALSE), c.verification_due_at, p.created_at
+		FROM challenges c
+		JOIN posts p ON c.completed_post_id = p.id
+		JOIN users u ON p.user_id = u.id
+		WHERE c.status = 'pending_verification' AND p.user_id != ?
+		AND NOT EXISTS (SELECT 1 FROM completion_reviews cr WHERE cr.post_id = p.id AND cr.reviewer_id = ?)`
+	if !isModerator(user) {
+		query += ` AND COALESCE(c.verification_escalated, FALSE) = FALSE`
+	}
+	query += ` ORDER BY p.created_at`
+
+	rows, err := h.db.Query(query, user.ID, user.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	pending := []models.PendingVerification{}
+	for rows.Next() {
+		var v models.PendingVerification
+		err := rows.Scan(&v.ChallengeID, &v.ChallengeTitle, &v.Points, &v.PostID, &v.UserID, &v.Username,
+			&v.MediaURL, &v.MediaType, &v.Caption, &v.Approvals, &v.Rejections, &v.Escalated, &v.DueAt, &v.SubmittedAt)
+		if err != nil {
+			http.Error(w, "Failed to scan verification", http.StatusInternalServerError)
+			return
+		}
+		pending = append(pending, v)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pending)
+}
+
+// ReviewCompletion approves or rejects a pending completion. One moderator
+// review settles it; otherwise it is verified once enough players approve,
+// and escalated to moderators once as many reject it.
+func (h *Handler) ReviewCompletion(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+	postID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Approve *bool `json:"approve"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Approve == nil {
+		http.Error(w, "approve is required", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	pending := pendingCompletion{postID: postID}
+	err = tx.QueryRow(`
+		SELECT c.id, c.title, c.points, p.user_id, COALESCE(c.verification_escalated, FALSE)
+		FROM challenges c
+		JOIN posts p ON c.completed_post_id = p.id
+		WHERE p.id = ? AND c.status = 'pending_verification' AND p.revoked = FALSE
+	`, postID).Scan(&pending.challengeID, &pending.title, &pending.points, &pending.userID, &pending.escalated)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Completion is not awaiting verification", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if pending.userID == user.ID {
+		http.Error(w, "You cannot review your own completion", http.StatusForbidden)
+		return
+	}
+	moderator := isModerator(user)
+	if pending.escalated && !moderator {
+		http.Error(w, "Completion has been escalated to moderators", http.StatusForbidden)
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO completion_reviews (post_id, reviewer_id, challenge_id, approved) VALUES (?, ?, ?, ?)
+		ON CONFLICT (post_id, reviewer_id) DO UPDATE SET approved = excluded.approved, created_at = CURRENT_TIMESTAMP
+	`, postID, user.ID, pending.challengeID, *req.Approve)
+	if err != nil {
+		http.Error(w, "Failed to record review", http.StatusInternalServerError)
+		return
+	}
+
+	var approvals, rejections int
+	err = tx.QueryRow(`
+		SELECT COUNT(CASE WHEN approved = TRUE THEN 1 END), COUNT(CASE WHEN approved = FALSE THEN 1 END)
+		FROM completion_reviews WHERE post_id = ?
+	`, postID).Scan(&approvals, &rejections)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	before, err := auditState(tx, pending.challengeID, postID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	status := "pending_verification"
+	switch {
+	case moderator && *req.Approve, !moderator && approvals >= h.cfg.VerificationApprovals:
+		err = verifyCompletion(tx, pending)
+		status = "completed"
+	case moderator:
+		err = rejectCompletion(tx, pending, "")
+		status = "rejected"
+	case rejections >= h.cfg.VerificationApprovals:
+		err = escalateCompletion(tx, pending)
+		status = "escalated"
+	}
+	if err != nil {
+		http.Error(w, "Failed to update completion", http.StatusInternalServerError)
+		return
+	}
+
+	// Only reviews that settle or escalate the completion change anything
+	if status != "pending_verification" {
+		after, err := auditState(tx, pending.challengeID, postID)
+		if err == nil {
+			action := map[string]string{"completed": "completion_verified", "rejected": "completion_rejected", "escalated": "completion_escalated"}[status]
+			err = recordAudit(tx, r, action, "challenge", pending.challengeID, before, after)
+		}
+		if err != nil {
+			http.Error(w, "Failed to record audit event", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	if status != "pending_verification" {
+		log.Printf("Completion of challenge %d by user %d %s after review by user %d", pending.challengeID, pending.userID, status, user.ID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":    "Review recorded",
+		"status":     status,
+		"approvals":  approvals,
+		"rejections": rejections,
+	})
+}
+
+// resolveOverdueVerifications approves or escalates completions nobody
+// settled before their review deadline, depending on the configured action
+func (h *Handler) resolveOverdueVerifications() error {
+	rows, err := h.db.Query(`
+		SELECT c.id, c.title, c.points, c.completed_by, c.completed_post_id, c.verification_due_at
+		FROM challenges c
+		WHERE c.status = 'pending_verification' AND c.verification_due_at IS NOT NULL
+		AND COALESCE(c.verification_escalated, FALSE) = FALSE
+	`)
+	if err != nil {
+		return err
+	}
+	var overdue []pendingCompletion
+	now := time.Now()
+	for rows.Next() {
+		var pending pendingCompletion
+		var dueAt time.Time
+		if err := rows.Scan(&pending.challengeID, &pending.title, &pending.points, &pending.userID, &pending.postID, &dueAt); err != nil {
+			rows.Close()
+			return err
+		}
+		if now.After(dueAt) {
+			overdue = append(overdue, pending)
+		}
+	}
+	rows.Close()
+
+	resolve, action := verifyCompletion, "completion_verified"
+	if h.cfg.VerificationTimeoutAction == "escalate" {
+		resolve, action = escalateCompletion, "completion_escalated"
+	}
+	for _, pending := range overdue {
+		if err := h.resolveOverdueVerification(pending, resolve, action); err != nil {
+			return err
+		}
+		log.Printf("Completion of challenge %d by user %d timed out waiting for review (%s)", pending.challengeID, pending.userID, h.cfg.VerificationTimeoutAction)
+	}
+	return nil
+}
+
+func (h *Handler) resolveOverdueVerification(pending pendingCompletion, resolve func(*sql.Tx, pendingCompletion) error, action string) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := auditState(tx, pending.challengeID, pending.postID)
+	if err != nil {
+		return err
+	}
+	if err := resolve(tx, pending); err != nil {
+		return err
+	}
+	after, err := auditState(tx, pending.challengeID, pending.postID)
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(tx, nil, action, "challenge", pending.challengeID, before, after); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
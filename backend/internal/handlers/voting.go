@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"orlando-app/internal/middleware"
+	"orlando-app/internal/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// validateVoting checks the voting settings of a request. Voting starts once
+// submissions close, so it needs an open challenge with an end_date.
+func validateVoting(req *models.CreateChallengeRequest) error {
+	if req.VotingEndsAt == nil {
+		return nil
+	}
+	if req.ChallengeType != "open" {
+		return errors.New("Only open challenges can be decided by a vote")
+	}
+	if req.EndDate == nil {
+		return errors.New("Voting needs an end_date to close submissions")
+	}
+	if !req.VotingEndsAt.After(*req.EndDate) {
+		return errors.New("voting_ends_at must be after end_date")
+	}
+	return nil
+}
+
+// votingFromForm reads voting_ends_at from a multipart challenge form. The
+// request's challenge type and end date must already be set.
+func votingFromForm(r *http.Request, req *models.CreateChallengeRequest) error {
+	if value := r.FormValue("voting_ends_at"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.New("Invalid voting_ends_at format. Use RFC3339 format")
+		}
+		req.VotingEndsAt = &parsed
+	}
+	return validateVoting(req)
+}
+
+// votingOpensAt is when submissions close: the end date plus the grace period
+func (h *Handler) votingOpensAt(challenge models.Challenge) time.Time {
+	return challenge.EndDate.Add(h.gracePeriod())
+}
+
+// votingClosed reports whether a challenge vote has finished, either because
+// its time ran out or because the challenge was awarded
+func votingClosed(challenge models.Challenge, now time.Time) bool {
+	return challenge.Status == "completed" || challenge.VotingClosedAt != nil || !now.Before(*challenge.VotingEndsAt)
+}
+
+// loadVotingChallenge fetches a challenge the user can see that is decided by a vote
+func (h *Handler) loadVotingChallenge(w http.ResponseWriter, r *http.Request, userID int) (*models.Challenge, bool) {
+	challengeID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid challenge ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	var challenge models.Challenge
+	err = h.db.QueryRow(`
+		SELECT `+challengeColumns+`
+		FROM challenges c WHERE c.id = ? AND `+unlockedSQL+`
+	`, challengeID, userID).Scan(challengeScanFields(&challenge)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Challenge not found", http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	if challenge.VotingEndsAt == nil || challenge.EndDate == nil {
+		http.Error(w, "Challenge is not decided by a vote", http.StatusBadRequest)
+		return nil, false
+	}
+	return &challenge, true
+}
+
+// VoteChallenge casts or changes the user's vote for an entry of an open
+// challenge. Each player has one vote and can't vote for their own entry.
+func (h *Handler) VoteChallenge(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+
+	challenge, ok := h.loadVotingChallenge(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		PostID int `json:"post_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	if votingClosed(*challenge, now) {
+		http.Error(w, "Voting has closed", http.StatusConflict)
+		return
+	}
+	if now.Before(h.votingOpensAt(*challenge)) {
+		http.Error(w, "Voting has not opened yet", http.StatusConflict)
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+
+	entries, err := h.openEntries(tx, *challenge)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	author := 0
+	for userID, postID := range entries.posts {
+		if postID == req.PostID {
+			author = userID
+		}
+	}
+	if author == 0 {
+		http.Error(w, "Post is not an entry in this challenge", http.StatusBadRequest)
+		return
+	}
+	if author == user.ID {
+		http.Error(w, "You cannot vote for your own entry", http.StatusForbidden)
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO challenge_votes (challenge_id, voter_id, post_id) VALUES (?, ?, ?)
+		ON CONFLICT (challenge_id, voter_id) DO UPDATE SET post_id = excluded.post_id, created_at = CURRENT_TIMESTAMP
+	`, challenge.ID, user.ID, req.PostID)
+	if err != nil {
+		http.Error(w, "Failed to record vote", http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, "Failed to commit transaction", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Vote recorded",
+		"post_id": req.PostID,
+	})
+}
+
+// GetChallengeVotes shows when a challenge vote runs and the user's own vote.
+// The counts are only included once voting has closed.
+func (h *Handler) GetChallengeVotes(w http.ResponseWriter, r *http.Request) {
+	user := r.Context().Value(middleware.UserContextKey).(models.User)
+
+	challenge, ok := h.loadVotingChallenge(w, r, user.ID)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	status := models.VotingStatus{
+		ChallengeID: challenge.ID,
+		OpensAt:     h.votingOpensAt(*challenge),
+		EndsAt:      *challenge.VotingEndsAt,
+		Closed:      votingClosed(*challenge, now),
+	}
+	status.Open = !status.Closed && !now.Before(status.OpensAt)
+
+	err := h.db.QueryRow(`
+		SELECT post_id FROM challenge_votes WHERE challenge_id = ? AND voter_id = ?
+	`, challenge.ID, user.ID).Scan(&status.MyVote)
+	if err != nil && err != sql.ErrNoRows {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if status.Closed {
+		rows, err := h.db.Query(`
+			SELECT p.id, p.user_id, u.username, COUNT(v.voter_id) as votes
+			FROM challenge_submissions cs
+			JOIN posts p ON cs.post_id = p.id
+			JOIN users u ON p.user_id = u.id
+			LEFT JOIN challenge_votes v ON v.challenge_id = cs.challenge_id AND v.post_id = p.id
+			WHERE cs.challenge_id = ? AND cs.post_id > 0 AND p.revoked = FALSE
+			GROUP BY p.id, p.user_id, u.username, p.created_at
+			ORDER BY votes DESC, p.created_at, p.id
+		`, challenge.ID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return
+		}
+		defer rows.Close()
+
+		status.Results = []models.VoteResult{}
+		for rows.Next() {
+			var result models.VoteResult
+			if err := rows.Scan(&result.PostID, &result.UserID, &result.Username, &result.Votes); err != nil {
+				http.Error(w, "Failed to scan vote result", http.StatusInternalServerError)
+				return
+			}
+			status.Results = append(status.Results, result)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// closeVotes awards open challenges whose vote has ended. Entries are ranked
+// by votes; ties go to the entry submitted first. Entries without votes only
+// get participation points, and a vote nobody took part in is left for an
+// admin to award.
+func (h *Handler) closeVotes() error {
+	rows, err := h.db.Query(`
+		SELECT id, voting_ends_at FROM challenges
+		WHERE challenge_type = 'open' AND status != 'completed'
+		AND voting_ends_at IS NOT NULL AND voting_closed_at IS NULL
+	`)
+	if err != nil {
+		return err
+	}
+	var due []int
+	now := time.Now()
+	for rows.Next() {
+		var id int
+		var endsAt time.Time
+		if err := rows.Scan(&id, &endsAt); err != nil {
+			rows.Close()
+			return err
+		}
+		if !now.Before(endsAt) {
+			due = append(due, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range due {
+		if err := h.closeVote(id); err != nil {
+			log.Printf("Failed to close vote on challenge %d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) closeVote(challengeID int) error {
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var challenge models.Challenge
+	err = tx.QueryRow(`
+		SELECT `+challengeColumns+`
+		FROM challenges c WHERE c.id = ?
+	`, challengeID).Scan(challengeScanFields(&challenge)...)
+	if err != nil {
+		return err
+	}
+
+	entries, err := h.openEntries(tx, challenge)
+	if err != nil {
+		return err
+	}
+
+	votes := make(map[int]int)
+	voteRows, err := tx.Query(`SELECT post_id, COUNT(*) FROM challenge_votes WHERE challenge_id = ? GROUP BY post_id`, challengeID)
+	if err != nil {
+		return err
+	}
+	for voteRows.Next() {
+		var postID, count int
+		if err := voteRows.Scan(&postID, &count); err != nil {
+			voteRows.Close()
+			return err
+		}
+		votes[postID] = count
+	}
+	voteRows.Close()
+
+	// entries.users is in submission order, so a stable sort breaks ties
+	// in favour of the earliest entry
+	var ranked []int
+	for _, userID := range entries.users {
+		if votes[entries.posts[userID]] > 0 {
+			ranked = append(ranked, userID)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return votes[entries.posts[ranked[i]]] > votes[entries.posts[ranked[j]]]
+	})
+	if placements := effectivePlacements(challenge); len(ranked) > len(placements) {
+		ranked = ranked[:len(placements)]
+	}
+
+	if len(ranked) == 0 {
+		if _, err := tx.Exec(`UPDATE challenges SET voting_closed_at = CURRENT_TIMESTAMP WHERE id = ?`, challengeID); err != nil {
+			return err
+		}
+		log.Printf("Vote on challenge %d closed without votes, leaving it for an admin to award", challengeID)
+		return tx.Commit()
+	}
+
+	before, err := auditState(tx, challengeID, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := awardPlacements(tx, challenge, ranked, entries); err != nil {
+		return err
+	}
+	after, err := auditState(tx, challengeID, 0)
+	if err != nil {
+		return err
+	}
+	if err := recordAudit(tx, nil, "challenge_award", "challenge", challengeID, before, after); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("Vote on challenge %d closed, awarded to users %v", challengeID, ranked)
+	return nil
+}
@@ -0,0 +1,65 @@
+package mentions
+
+import (
+	"unicode"
+)
+
+// MaxMentions bounds how many mentions a single caption or comment can make
+const MaxMentions = 20
+
+// Span is an @username mention in a piece of text. Start and End are UTF-16
+// code unit offsets (End exclusive) covering the @ and the name, so they
+// index the text the way JavaScript strings do even with emoji before them.
+type Span struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// utf16Len is how many UTF-16 code units r takes: two for characters outside
+// the Basic Multilingual Plane such as most emoji, one otherwise
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// isNameChar reports whether r can be part of a mentioned username
+func isNameChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-'
+}
+
+// Parse finds the @username mentions in text, e.g. "nice one @mickey!" ->
+// mickey. A mention has to start the text or follow a character that can't
+// be part of a name, so e-mail addresses aren't picked up, and a trailing
+// "." or "-" is left out so mentions can end a sentence.
+func Parse(text string) []Span {
+	runes := []rune(text)
+
+	// offsets[i] is where runes[i] starts in UTF-16 code units
+	offsets := make([]int, len(runes)+1)
+	for i, r := range runes {
+		offsets[i+1] = offsets[i] + utf16Len(r)
+	}
+
+	var spans []Span
+	for i := 0; i < len(runes) && len(spans) < MaxMentions; i++ {
+		if runes[i] != '@' || (i > 0 && (isNameChar(runes[i-1]) || runes[i-1] == '@')) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isNameChar(runes[end]) {
+			end++
+		}
+		for end > i+1 && (runes[end-1] == '.' || runes[end-1] == '-') {
+			end--
+		}
+		if end == i+1 {
+			continue
+		}
+		spans = append(spans, Span{Username: string(runes[i+1 : end]), Start: offsets[i], End: offsets[end]})
+		i = end - 1
+	}
+	return spans
+}
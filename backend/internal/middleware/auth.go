@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"orlando-app/internal/config"
 	"orlando-app/internal/models"
+	"orlando-app/internal/scoring"
 	"strings"
 	"time"
 
@@ -63,20 +64,8 @@ func AuthMiddleware(db *sql.DB, cfg *config.Config) func(http.Handler) http.Hand
 			err = db.QueryRow(`
 				SELECT 
 					u.id, u.username, u.first_name, u.last_name, u.profile_image, u.role, u.created_at,
-					COALESCE(SUM(CASE 
-						WHEN c.status = 'completed' AND 
-							 ((c.challenge_type = 'exclusive') OR 
-							  (c.challenge_type = 'open' AND c.completed_by = u.id))
-						THEN c.points 
-						ELSE 0 
-					END), 0) as total_points,
-					COUNT(CASE 
-						WHEN c.status = 'completed' AND 
-							 ((c.challenge_type = 'exclusive') OR 
-							  (c.challenge_type = 'open' AND c.completed_by = u.id))
-						THEN p.id 
-						ELSE NULL 
-					END) as challenges_completed
+					`+scoring.PointsSQL+` as total_points,
+					`+scoring.CompletedSQL+` as challenges_completed
 				FROM users u
 				LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 				LEFT JOIN challenges c ON p.challenge_id = c.id
@@ -144,20 +133,8 @@ func OptionalAuthMiddleware(db *sql.DB, cfg *config.Config) func(http.Handler) h
 			err = db.QueryRow(`
 				SELECT 
 					u.id, u.username, u.first_name, u.last_name, u.profile_image, u.role, u.created_at,
-					COALESCE(SUM(CASE 
-						WHEN c.status = 'completed' AND 
-							 ((c.challenge_type = 'exclusive') OR 
-							  (c.challenge_type = 'open' AND c.completed_by = u.id))
-						THEN c.points 
-						ELSE 0 
-					END), 0) as total_points,
-					COUNT(CASE 
-						WHEN c.status = 'completed' AND 
-							 ((c.challenge_type = 'exclusive') OR 
-							  (c.challenge_type = 'open' AND c.completed_by = u.id))
-						THEN p.id 
-						ELSE NULL 
-					END) as challenges_completed
+					`+scoring.PointsSQL+` as total_points,
+					`+scoring.CompletedSQL+` as challenges_completed
 				FROM users u
 				LEFT JOIN posts p ON u.id = p.user_id AND p.revoked = FALSE
 				LEFT JOIN challenges c ON p.challenge_id = c.id
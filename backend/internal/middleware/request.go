@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net"
+	"net/http"
+	"orlando-app/internal/config"
+	"strings"
+)
+
+const RequestIDContextKey contextKey = "request_id"
+const ClientIPContextKey contextKey = "client_ip"
+
+// maxRequestIDLength bounds request IDs taken from the X-Request-ID header
+const maxRequestIDLength = 64
+
+// RequestIDMiddleware tags every request with an ID, reusing the X-Request-ID
+// header when the reverse proxy sets one, and echoes it in the response so
+// log lines and audit events can be matched to a request
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			requestID = hex.EncodeToString(buf)
+		}
+
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := context.WithValue(r.Context(), RequestIDContextKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestID returns the ID RequestIDMiddleware gave the request
+func RequestID(r *http.Request) string {
+	requestID, _ := r.Context().Value(RequestIDContextKey).(string)
+	return requestID
+}
+
+// ClientIPMiddleware works out the caller's address once per request. The
+// X-Real-IP header is only believed when the connection comes from one of
+// the trusted proxies, since anyone reaching the port directly can set it.
+func ClientIPMiddleware(cfg *config.Config) func(http.Handler) http.Handler {
+	var trusted []*net.IPNet
+	for _, proxy := range cfg.TrustedProxies {
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			trusted = append(trusted, network)
+		} else if ip := parseIP(proxy); ip != nil {
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+		} else {
+			log.Printf("Ignoring invalid TRUSTED_PROXIES entry: %s", proxy)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := remoteIP(r)
+			if realIP := parseIP(r.Header.Get("X-Real-IP")); realIP != nil && fromProxy(trusted, ip) {
+				ip = realIP.String()
+			}
+			ctx := context.WithValue(r.Context(), ClientIPContextKey, ip)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientIP returns the caller's address as ClientIPMiddleware found it
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+		return ip
+	}
+	return remoteIP(r)
+}
+
+// remoteIP is the address of the connection itself
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// parseIP parses an address, using the 4 byte form for IPv4 so it matches
+// IPv4 networks
+func parseIP(value string) net.IP {
+	ip := net.ParseIP(strings.TrimSpace(value))
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
+
+// fromProxy reports whether the connection address is a trusted proxy
+func fromProxy(trusted []*net.IPNet, remote string) bool {
+	ip := parseIP(remote)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
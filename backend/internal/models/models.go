@@ -1,6 +1,11 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -15,6 +20,13 @@ type User struct {
 	TotalPoints          int       `json:"total_points" db:"total_points"`
 	ChallengesCompleted  int       `json:"challenges_completed" db:"challenges_completed"`
 	CreatedAt            time.Time `json:"created_at" db:"created_at"`
+	
+	// Posts in the player's gallery by challenge type; only set by GetUser
+	PostsByChallengeType map[string]int `json:"posts_by_challenge_type,omitempty"`
+	FollowersCount       int            `json:"followers_count"`
+	FollowingCount       int            `json:"following_count"`
+	// Whether the viewer follows this player; unset on their own profile
+	IsFollowing *bool `json:"is_following,omitempty"`
 }
 
 type Challenge struct {
@@ -31,12 +43,49 @@ type Challenge struct {
 	StartDate       *time.Time `json:"start_date" db:"start_date"`
 	EndDate         *time.Time `json:"end_date" db:"end_date"`
 	ChallengeType   string    `json:"challenge_type" db:"challenge_type"`
+	GeofenceLat     *float64  `json:"geofence_lat" db:"geofence_lat"`
+	GeofenceLon     *float64  `json:"geofence_lon" db:"geofence_lon"`
+	GeofenceRadius  *float64  `json:"geofence_radius_m" db:"geofence_radius_m"`
+	GeofencePark    *string   `json:"geofence_park" db:"geofence_park"`
+	ClaimTTLMinutes *int       `json:"claim_ttl_minutes" db:"claim_ttl_minutes"`
+	ClaimExpiresAt  *time.Time `json:"claim_expires_at" db:"claim_expires_at"`
+	ClaimExtended   bool       `json:"claim_extended" db:"claim_extended"`
+	Category        *string    `json:"category" db:"category"`
+	Tags            []string   `json:"tags"`
+	PrerequisiteIDs []int      `json:"prerequisite_ids"`
+	MinPoints       *int       `json:"min_points" db:"min_points"`
+	ShowWhenLocked  bool       `json:"show_when_locked" db:"show_when_locked"`
+	Hidden          bool       `json:"hidden" db:"hidden"`
+	UnlockScope     *string    `json:"unlock_scope,omitempty" db:"unlock_scope"`
+	UnlockedAt      *time.Time `json:"unlocked_at,omitempty" db:"unlocked_at"`
+	// Points for 1st, 2nd, 3rd... place on open challenges; empty means the
+	// winner takes the challenge's points
+	PlacementPoints     PointsList `json:"placement_points" db:"placement_points"`
+	ParticipationPoints int        `json:"participation_points" db:"participation_points"`
+	// Set on open challenges decided by a player vote, which runs from the end
+	// of the submission window until VotingEndsAt
+	VotingEndsAt   *time.Time `json:"voting_ends_at,omitempty" db:"voting_ends_at"`
+	VotingClosedAt *time.Time `json:"voting_closed_at,omitempty" db:"voting_closed_at"`
+	// Exclusive completions of challenges requiring verification wait in
+	// pending_verification for peer or moderator review
+	RequiresVerification  bool       `json:"requires_verification" db:"requires_verification"`
+	VerificationDueAt     *time.Time `json:"verification_due_at,omitempty" db:"verification_due_at"`
+	VerificationEscalated bool       `json:"verification_escalated" db:"verification_escalated"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 	
 	// Joined fields for display
-	CompletedByUsername *string `json:"completed_by_username,omitempty"`
-	AssignedToUsername  *string `json:"assigned_to_username,omitempty"`
+	CompletedByUsername     *string `json:"completed_by_username,omitempty"`
+	AssignedToUsername      *string `json:"assigned_to_username,omitempty"`
+	CompletedLocationStatus *string `json:"completed_location_status,omitempty"`
+	// Admin only
+	UnlockCode  *string `json:"unlock_code,omitempty"`
+	UnlockCount *int    `json:"unlock_count,omitempty"`
 	Submissions         []ChallengeSubmission `json:"submissions,omitempty"`
+
+	// Set for the requesting user when the challenge is shown as a locked teaser
+	Locked                 bool  `json:"locked,omitempty"`
+	MissingPrerequisiteIDs []int `json:"missing_prerequisite_ids,omitempty"`
+	PointsNeeded           int   `json:"points_needed,omitempty"`
 }
 
 type Post struct {
@@ -48,6 +97,11 @@ type Post struct {
 	Caption     *string   `json:"caption" db:"caption"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	Revoked     bool      `json:"revoked" db:"revoked"`
+	RevokeReason *string  `json:"revoke_reason,omitempty" db:"revoke_reason"`
+	// verified, unverified or outside; nil when the challenge has no geofence
+	LocationStatus *string `json:"location_status,omitempty" db:"location_status"`
+	// approved or rejected once a moderator has looked at the post
+	ModerationStatus *string `json:"moderation_status,omitempty" db:"moderation_status"`
 	
 	// Joined fields
 	Username             string  `json:"username,omitempty"`
@@ -60,6 +114,19 @@ type Post struct {
 	LikesCount           int     `json:"likes_count,omitempty"`
 	CommentsCount        int     `json:"comments_count,omitempty"`
 	UserLiked            bool    `json:"user_liked,omitempty"`
+	Mentions             []Mention `json:"mentions,omitempty"`
+	// Reaction counts keyed by reaction name; likes_count is their total and
+	// user_liked is set when the viewer left any reaction
+	Reactions    map[string]int `json:"reactions,omitempty"`
+	UserReaction *string        `json:"user_reaction,omitempty"`
+	// Ranking score, only set on ranked feeds
+	Score *float64 `json:"score,omitempty"`
+}
+
+// FeedPage is one page of the feed. NextCursor is nil on the last page.
+type FeedPage struct {
+	Posts      []Post  `json:"posts"`
+	NextCursor *string `json:"next_cursor"`
 }
 
 type Like struct {
@@ -68,15 +135,47 @@ type Like struct {
 }
 
 type Comment struct {
-	ID        int       `json:"id" db:"id"`
-	UserID    int       `json:"user_id" db:"user_id"`
-	PostID    int       `json:"post_id" db:"post_id"`
-	Content   string    `json:"content" db:"content"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID        int        `json:"id" db:"id"`
+	UserID    int        `json:"user_id" db:"user_id"`
+	PostID    int        `json:"post_id" db:"post_id"`
+	ParentID  *int       `json:"parent_id,omitempty" db:"parent_id"`
+	Depth     int        `json:"depth" db:"depth"`
+	Content   string     `json:"content" db:"content"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	Edited    bool       `json:"edited"`
+	EditedAt  *time.Time `json:"edited_at,omitempty" db:"edited_at"`
+	
+	// Deleted comments that still have replies stay in the thread with their
+	// content blanked
+	Deleted    bool `json:"deleted,omitempty"`
+	ReplyCount int  `json:"reply_count"`
 	
 	// Joined fields
-	Username         string  `json:"username,omitempty"`
-	UserProfileImage *string `json:"user_profile_image,omitempty"`
+	Username         string    `json:"username,omitempty"`
+	UserProfileImage *string   `json:"user_profile_image,omitempty"`
+	Mentions         []Mention `json:"mentions,omitempty"`
+}
+
+// Mention is an @username in a caption or comment. Start and End are UTF-16
+// code unit offsets into the text, End exclusive, covering the @ and the name.
+type Mention struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+}
+
+// TrendingTag is a hashtag with how often it was used in the trending window
+type TrendingTag struct {
+	Tag   string `json:"tag"`
+	Uses  int    `json:"uses"`
+	Posts int    `json:"posts"`
+}
+
+// CommentEdit is an earlier version of an edited comment
+type CommentEdit struct {
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"edited_at"`
 }
 
 type LoginRequest struct {
@@ -104,6 +203,23 @@ type CreateChallengeRequest struct {
 	StartDate     *time.Time `json:"start_date"`
 	EndDate       *time.Time `json:"end_date"`
 	ChallengeType string     `json:"challenge_type"`
+	GeofenceLat    *float64  `json:"geofence_lat"`
+	GeofenceLon    *float64  `json:"geofence_lon"`
+	GeofenceRadius *float64  `json:"geofence_radius_m"`
+	GeofencePark   *string   `json:"geofence_park"`
+	ClaimTTLMinutes *int     `json:"claim_ttl_minutes"`
+	Category       *string   `json:"category"`
+	Tags           []string  `json:"tags"`
+	PrerequisiteIDs []int    `json:"prerequisite_ids"`
+	MinPoints      *int      `json:"min_points"`
+	ShowWhenLocked bool      `json:"show_when_locked"`
+	Hidden         bool      `json:"hidden"`
+	UnlockCode     *string   `json:"unlock_code"`
+	UnlockScope    string    `json:"unlock_scope"`
+	PlacementPoints     []int `json:"placement_points"`
+	ParticipationPoints int   `json:"participation_points"`
+	VotingEndsAt        *time.Time `json:"voting_ends_at"`
+	RequiresVerification bool      `json:"requires_verification"`
 }
 
 type CompleteActivityRequest struct {
@@ -111,7 +227,8 @@ type CompleteActivityRequest struct {
 }
 
 type CreateCommentRequest struct {
-	Content string `json:"content"`
+	Content  string `json:"content"`
+	ParentID *int   `json:"parent_id"`
 }
 
 type ChallengeSubmission struct {
@@ -124,4 +241,255 @@ type ChallengeSubmission struct {
 	// Joined fields for display
 	Username         string  `json:"username,omitempty"`
 	UserProfileImage *string `json:"user_profile_image,omitempty"`
+	LocationStatus   *string `json:"location_status,omitempty"`
+	// Set once an open challenge has been awarded; Place is nil for participation credit
+	Place            *int    `json:"place,omitempty"`
+	PointsAwarded    *int    `json:"points_awarded,omitempty"`
+}
+
+// ChallengeGroup is one bucket of a grouped challenge list. Key is empty for
+// challenges that have no value for the grouping.
+type ChallengeGroup struct {
+	Key        string      `json:"key"`
+	Challenges []Challenge `json:"challenges"`
+}
+
+// PointsList is a list of points stored as comma-separated text, e.g. "5,3,1"
+type PointsList []int
+
+func (l *PointsList) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		*l = PointsList{}
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into PointsList", src)
+	}
+
+	list := PointsList{}
+	for _, field := range strings.Split(text, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		points, err := strconv.Atoi(field)
+		if err != nil {
+			return err
+		}
+		list = append(list, points)
+	}
+	*l = list
+	return nil
+}
+
+func (l PointsList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	fields := make([]string, len(l))
+	for i, points := range l {
+		fields[i] = strconv.Itoa(points)
+	}
+	return strings.Join(fields, ","), nil
+}
+
+// ChallengePlacement is the credit one submission received when an open
+// challenge was awarded. Place is nil for participation credit.
+type ChallengePlacement struct {
+	ChallengeID int       `json:"challenge_id"`
+	UserID      int       `json:"user_id"`
+	Username    string    `json:"username,omitempty"`
+	PostID      int       `json:"post_id"`
+	Place       *int      `json:"place"`
+	Points      int       `json:"points"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ModerationItem is a post waiting in the admin moderation queue. Reasons
+// says why it is there: pending_submission, pending_verification,
+// location_outside, duplicate_media and/or reported.
+type ModerationItem struct {
+	PostID           int       `json:"post_id"`
+	ChallengeID      int       `json:"challenge_id"`
+	ChallengeTitle   string    `json:"challenge_title"`
+	ChallengeType    string    `json:"challenge_type"`
+	UserID           int       `json:"user_id"`
+	Username         string    `json:"username"`
+	MediaURL         string    `json:"media_url"`
+	MediaType        string    `json:"media_type"`
+	Caption          *string   `json:"caption"`
+	LocationStatus   *string   `json:"location_status,omitempty"`
+	ModerationStatus *string   `json:"moderation_status,omitempty"`
+	Reasons          []string  `json:"reasons"`
+	DuplicatePostIDs []int     `json:"duplicate_post_ids,omitempty"`
+	Reports          int       `json:"reports"`
+	ReportReasons    []string  `json:"report_reasons,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ReportedContent is a post or comment with open player reports. Reasons
+// counts the reports per category; Hidden says whether enough reports came
+// in to take it out of the feed.
+type ReportedContent struct {
+	TargetType      string         `json:"target_type"`
+	TargetID        int            `json:"target_id"`
+	PostID          int            `json:"post_id"`
+	AuthorID        int            `json:"author_id"`
+	AuthorUsername  string         `json:"author_username"`
+	Content         *string        `json:"content"`
+	Reports         int            `json:"reports"`
+	Reasons         map[string]int `json:"reasons"`
+	Details         []string       `json:"details,omitempty"`
+	Hidden          bool           `json:"hidden"`
+	FirstReportedAt time.Time      `json:"first_reported_at"`
+	LastReportedAt  time.Time      `json:"last_reported_at"`
+}
+
+// PendingVerification is an exclusive completion waiting for review
+type PendingVerification struct {
+	ChallengeID    int        `json:"challenge_id"`
+	ChallengeTitle string     `json:"challenge_title"`
+	Points         int        `json:"points"`
+	PostID         int        `json:"post_id"`
+	UserID         int        `json:"user_id"`
+	Username       string     `json:"username"`
+	MediaURL       string     `json:"media_url"`
+	MediaType      string     `json:"media_type"`
+	Caption        *string    `json:"caption"`
+	Approvals      int        `json:"approvals"`
+	Rejections     int        `json:"rejections"`
+	Escalated      bool       `json:"escalated"`
+	DueAt          *time.Time `json:"due_at"`
+	SubmittedAt    time.Time  `json:"submitted_at"`
+}
+
+// VoteResult is the vote count of one entry in a challenge vote
+type VoteResult struct {
+	PostID   int    `json:"post_id"`
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	Votes    int    `json:"votes"`
+}
+
+// VotingStatus describes the player vote of an open challenge. Results are
+// only shown once voting has closed.
+type VotingStatus struct {
+	ChallengeID int          `json:"challenge_id"`
+	OpensAt     time.Time    `json:"opens_at"`
+	EndsAt      time.Time    `json:"ends_at"`
+	Open        bool         `json:"open"`
+	Closed      bool         `json:"closed"`
+	MyVote      *int         `json:"my_vote_post_id"`
+	Results     []VoteResult `json:"results,omitempty"`
+}
+
+// AuditEvent records one admin or scoring mutation. Actor is empty for
+// changes made by the scheduler; Before and After are JSON snapshots of the
+// target.
+type AuditEvent struct {
+	ID            int             `json:"id"`
+	ActorID       *int            `json:"actor_id"`
+	ActorUsername *string         `json:"actor_username"`
+	Action        string          `json:"action"`
+	TargetType    string          `json:"target_type"`
+	TargetID      int             `json:"target_id"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	IP            *string         `json:"ip"`
+	RequestID     *string         `json:"request_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// AuditLog is one page of audit events, newest first
+type AuditLog struct {
+	Events []AuditEvent `json:"events"`
+	Page   int          `json:"page"`
+	Limit  int          `json:"limit"`
+	Total  int          `json:"total"`
+}
+
+// PostReaction is a player's reaction in a post's who-liked list. CreatedAt
+// is nil for likes left before reactions recorded their time.
+type PostReaction struct {
+	UserID           int        `json:"user_id"`
+	Username         string     `json:"username"`
+	UserProfileImage *string    `json:"user_profile_image,omitempty"`
+	Reaction         string     `json:"reaction"`
+	CreatedAt        *time.Time `json:"created_at"`
+}
+
+// PostReactions is one page of a post's who-liked list with the reaction
+// counts over all of it
+type PostReactions struct {
+	Reactions []PostReaction `json:"reactions"`
+	Counts    map[string]int `json:"counts"`
+	Page      int            `json:"page"`
+	Limit     int            `json:"limit"`
+	Total     int            `json:"total"`
+}
+
+// FollowEntry is a player in a follower or following list
+type FollowEntry struct {
+	UserID       int       `json:"user_id"`
+	Username     string    `json:"username"`
+	ProfileImage *string   `json:"profile_image,omitempty"`
+	FollowedAt   time.Time `json:"followed_at"`
+}
+
+// FollowList is one page of a follower or following list
+type FollowList struct {
+	Users []FollowEntry `json:"users"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+	Total int           `json:"total"`
+}
+
+// ChallengeUnlock records a player entering the code of a hidden challenge
+type ChallengeUnlock struct {
+	ChallengeID    int       `json:"challenge_id"`
+	ChallengeTitle string    `json:"challenge_title"`
+	UserID         int       `json:"user_id"`
+	Username       string    `json:"username"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// HeldChallenge is a challenge a user has picked but not yet submitted
+type HeldChallenge struct {
+	ID            int    `json:"id"`
+	Title         string `json:"title"`
+	ChallengeType string `json:"challenge_type"`
+}
+
+type Notification struct {
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Kind        string     `json:"kind" db:"kind"`
+	Message     string     `json:"message" db:"message"`
+	ChallengeID *int       `json:"challenge_id,omitempty" db:"challenge_id"`
+	PostID      *int       `json:"post_id,omitempty" db:"post_id"`
+	ReadAt      *time.Time `json:"read_at" db:"read_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SearchResult is one match of a search. Type is challenge, post, comment or
+// user, and ID is the id of that row; comments also carry their post.
+type SearchResult struct {
+	Type    string  `json:"type"`
+	ID      int     `json:"id"`
+	PostID  *int    `json:"post_id,omitempty"`
+	Title   string  `json:"title"`
+	Snippet string  `json:"snippet"`
+	Score   float64 `json:"score"`
+}
+
+// SearchResults is one page of search results, best match first
+type SearchResults struct {
+	Results []SearchResult `json:"results"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+	Total   int            `json:"total"`
 }
\ No newline at end of file
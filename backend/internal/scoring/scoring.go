@@ -0,0 +1,18 @@
+package scoring
+
+// creditSQL is what a non-revoked post p earns on challenge c: the full points
+// of a completed exclusive challenge, or the placement awarded to that post on
+// a completed open challenge. It is NULL when the post earns nothing.
+const creditSQL = `CASE
+		WHEN c.status = 'completed' AND c.challenge_type = 'exclusive' THEN c.points
+		WHEN c.status = 'completed' AND c.challenge_type = 'open' THEN
+			(SELECT cpl.points FROM challenge_placements cpl WHERE cpl.challenge_id = c.id AND cpl.post_id = p.id)
+	END`
+
+// PointsSQL and CompletedSQL aggregate a user's total points and completed
+// challenges. They expect posts aliased as "p", joined with
+// p.revoked = FALSE, and challenges aliased as "c".
+const (
+	PointsSQL    = `COALESCE(SUM(` + creditSQL + `), 0)`
+	CompletedSQL = `COUNT(` + creditSQL + `)`
+)
@@ -0,0 +1,52 @@
+package tags
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isHashtagChar reports whether r can be part of a #hashtag
+func isHashtagChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// NormalizeHashtag lower-cases a hashtag and strips its leading #, e.g.
+// "#MagicKingdom" -> "magickingdom". It returns "" for anything that isn't a
+// valid hashtag.
+func NormalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if tag == "" || len(tag) > MaxLength {
+		return ""
+	}
+	for _, r := range tag {
+		if !isHashtagChar(r) {
+			return ""
+		}
+	}
+	return tag
+}
+
+// Hashtags finds the #hashtags in a caption or comment, normalized and
+// without duplicates, in the order they first appear. A hashtag has to start
+// the text or follow a character that can't be part of one, so URL fragments
+// like "page#top" aren't picked up.
+func Hashtags(text string) []string {
+	runes := []rune(text)
+	seen := make(map[string]bool)
+	var result []string
+	for i := 0; i < len(runes) && len(result) < MaxTags; i++ {
+		if runes[i] != '#' || (i > 0 && (isHashtagChar(runes[i-1]) || runes[i-1] == '#')) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && isHashtagChar(runes[end]) {
+			end++
+		}
+		if tag := NormalizeHashtag(string(runes[i+1 : end])); tag != "" && !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+		i = end - 1
+	}
+	return result
+}
@@ -0,0 +1,90 @@
+package tags
+
+import (
+	"fmt"
+	"orlando-app/internal/geo"
+	"strings"
+)
+
+// MaxTags and MaxLength bound what a single challenge can carry
+const (
+	MaxTags   = 20
+	MaxLength = 64
+)
+
+// ParkKey is the tag key used for park grouping. Its values are normalized
+// the same way as geofence parks so the two can be compared.
+const ParkKey = "park"
+
+// Split returns the key and value of a "key:value" tag. Plain tags have an
+// empty key.
+func Split(tag string) (key, value string) {
+	if i := strings.Index(tag, ":"); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return "", tag
+}
+
+// NormalizeCategory trims and lower-cases a category, e.g. " Ride " -> "ride"
+func NormalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// Normalize cleans up a single tag: keys are lower-cased, whitespace is
+// collapsed and park values are mapped to their park identifier
+func Normalize(tag string) string {
+	key, value := Split(tag)
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.Join(strings.Fields(value), " ")
+	if key == "" {
+		return value
+	}
+	if key == ParkKey {
+		value = geo.NormalizeParkName(value)
+	}
+	return key + ":" + value
+}
+
+// Parse splits a delimited list such as "kind:ride, park:Epcot"
+func Parse(list string, sep string) []string {
+	if strings.TrimSpace(list) == "" {
+		return nil
+	}
+	return strings.Split(list, sep)
+}
+
+// NormalizeAll normalizes a tag list, dropping empties and case-insensitive
+// duplicates, and rejects lists that are too long
+func NormalizeAll(raw []string) ([]string, error) {
+	seen := make(map[string]bool)
+	result := []string{}
+	for _, tag := range raw {
+		tag = Normalize(tag)
+		if _, value := Split(tag); value == "" {
+			continue
+		}
+		if len(tag) > MaxLength {
+			return nil, fmt.Errorf("Tag %q is longer than %d characters", tag, MaxLength)
+		}
+		if seen[strings.ToLower(tag)] {
+			continue
+		}
+		seen[strings.ToLower(tag)] = true
+		result = append(result, tag)
+	}
+	if len(result) > MaxTags {
+		return nil, fmt.Errorf("A challenge can have at most %d tags", MaxTags)
+	}
+	return result, nil
+}
+
+// Values returns the values of every tag with the given key
+func Values(list []string, key string) []string {
+	var values []string
+	for _, tag := range list {
+		if k, v := Split(tag); k != "" && strings.EqualFold(k, key) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -33,6 +33,27 @@ CREATE TABLE IF NOT EXISTS challenges (
     start_date TIMESTAMP,
     end_date TIMESTAMP,
     challenge_type VARCHAR(50) DEFAULT 'exclusive',
+    geofence_lat DOUBLE PRECISION,
+    geofence_lon DOUBLE PRECISION,
+    geofence_radius_m DOUBLE PRECISION,
+    geofence_park VARCHAR(100),
+    claim_ttl_minutes INTEGER,
+    claim_expires_at TIMESTAMP,
+    claim_extended BOOLEAN DEFAULT FALSE,
+    category VARCHAR(50),
+    min_points INTEGER,
+    show_when_locked BOOLEAN DEFAULT FALSE,
+    hidden BOOLEAN DEFAULT FALSE,
+    unlock_code VARCHAR(100),
+    unlock_scope VARCHAR(20),
+    unlocked_at TIMESTAMP,
+    placement_points VARCHAR(100),
+    participation_points INTEGER DEFAULT 0,
+    voting_ends_at TIMESTAMP,
+    voting_closed_at TIMESTAMP,
+    requires_verification BOOLEAN DEFAULT FALSE,
+    verification_due_at TIMESTAMP,
+    verification_escalated BOOLEAN DEFAULT FALSE,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -45,32 +66,108 @@ CREATE TABLE IF NOT EXISTS posts (
     media_type VARCHAR(50) NOT NULL,
     caption TEXT,
     revoked BOOLEAN DEFAULT FALSE,
+    revoke_reason TEXT,
+    revoked_at TIMESTAMP,
+    location_status VARCHAR(50),
+    media_hash VARCHAR(64),
+    moderation_status VARCHAR(50),
+    removed_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- Create likes table
-CREATE TABLE IF NOT EXISTS likes (
+-- Create reactions table; each player leaves at most one reaction per post
+-- (heart, laugh, fire, love, clap or wow). It replaces the old likes table.
+CREATE TABLE IF NOT EXISTS reactions (
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    reaction VARCHAR(20) NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     PRIMARY KEY (user_id, post_id)
 );
 
+-- Likes from before reactions become hearts, keeping their time. The old
+-- table goes once copied so a reaction removed later doesn't come back.
+DO $$
+BEGIN
+    IF to_regclass('likes') IS NOT NULL THEN
+        INSERT INTO reactions (user_id, post_id, reaction, created_at)
+        SELECT user_id, post_id, 'heart', created_at FROM likes
+        ON CONFLICT (user_id, post_id) DO NOTHING;
+        DROP TABLE likes;
+    END IF;
+END $$;
+
+-- Create follows table; a player's following feed shows posts by followees
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
 -- Create comments table
 CREATE TABLE IF NOT EXISTS comments (
     id SERIAL PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
     content TEXT NOT NULL,
+    parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    depth INTEGER DEFAULT 0,
+    removed_at TIMESTAMP,
+    edited_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create comment edits table (the content before each edit)
+CREATE TABLE IF NOT EXISTS comment_edits (
+    id SERIAL PRIMARY KEY,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    content TEXT NOT NULL,
+    edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create mentions table for @username mentions in captions and comments;
+-- comment_id is NULL for mentions in a post's caption
+CREATE TABLE IF NOT EXISTS mentions (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_pos INTEGER NOT NULL,
+    end_pos INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create hashtags table for #hashtags in captions and comments, stored
+-- lower-cased without the #; comment_id is NULL for a post's caption
+CREATE TABLE IF NOT EXISTS hashtags (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    comment_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+-- Create post_scores table; the scheduler refreshes each post's weighted
+-- engagement and its age-decayed rank for the ranked feed
+CREATE TABLE IF NOT EXISTS post_scores (
+    post_id INTEGER PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    engagement DOUBLE PRECISION NOT NULL,
+    rank DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Create temp_media table for temporary uploads
 CREATE TABLE IF NOT EXISTS temp_media (
     media_id VARCHAR(255) PRIMARY KEY,
     user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     media_url VARCHAR(255) NOT NULL,
     media_type VARCHAR(50) NOT NULL,
+    gps_lat DOUBLE PRECISION,
+    gps_lon DOUBLE PRECISION,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     expires_at TIMESTAMP NOT NULL
 );
@@ -85,24 +182,163 @@ CREATE TABLE IF NOT EXISTS challenge_submissions (
     UNIQUE(challenge_id, user_id)
 );
 
+-- Create notifications table
+CREATE TABLE IF NOT EXISTS notifications (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    challenge_id INTEGER REFERENCES challenges(id) ON DELETE CASCADE,
+    post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create challenge tags table
+CREATE TABLE IF NOT EXISTS challenge_tags (
+    challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    tag VARCHAR(64) NOT NULL,
+    PRIMARY KEY (challenge_id, tag)
+);
+
+-- Create challenge prerequisites table
+CREATE TABLE IF NOT EXISTS challenge_prerequisites (
+    challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    required_challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    PRIMARY KEY (challenge_id, required_challenge_id)
+);
+
+-- Create challenge unlocks table
+CREATE TABLE IF NOT EXISTS challenge_unlocks (
+    challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (challenge_id, user_id)
+);
+
+-- Create unlock attempts table
+CREATE TABLE IF NOT EXISTS unlock_attempts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create challenge placements table
+CREATE TABLE IF NOT EXISTS challenge_placements (
+    challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id INTEGER REFERENCES posts(id) ON DELETE CASCADE,
+    place INTEGER,
+    points INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (challenge_id, user_id)
+);
+
+-- Create challenge votes table
+CREATE TABLE IF NOT EXISTS challenge_votes (
+    challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    voter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (challenge_id, voter_id)
+);
+
+-- Create completion reviews table
+CREATE TABLE IF NOT EXISTS completion_reviews (
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    reviewer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    challenge_id INTEGER NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    approved BOOLEAN NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, reviewer_id)
+);
+
+-- Create post reports table
+CREATE TABLE IF NOT EXISTS post_reports (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    UNIQUE(post_id, reporter_id)
+);
+
+-- Create comment reports table
+CREATE TABLE IF NOT EXISTS comment_reports (
+    id SERIAL PRIMARY KEY,
+    comment_id INTEGER NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    reporter_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    UNIQUE(comment_id, reporter_id)
+);
+
+-- Create moderation actions table
+CREATE TABLE IF NOT EXISTS moderation_actions (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    moderator_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create audit events table (actor_id is NULL for scheduled jobs)
+CREATE TABLE IF NOT EXISTS audit_events (
+    id SERIAL PRIMARY KEY,
+    actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INTEGER NOT NULL,
+    before_state JSONB,
+    after_state JSONB,
+    ip VARCHAR(64),
+    request_id VARCHAR(64),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
 -- Create indexes for better performance
 CREATE INDEX IF NOT EXISTS idx_challenges_status ON challenges(status);
 CREATE INDEX IF NOT EXISTS idx_challenges_assigned_to ON challenges(assigned_to);
 CREATE INDEX IF NOT EXISTS idx_challenges_completed_by ON challenges(completed_by);
 CREATE INDEX IF NOT EXISTS idx_challenges_type ON challenges(challenge_type);
 CREATE INDEX IF NOT EXISTS idx_challenges_created_at ON challenges(created_at);
+CREATE INDEX IF NOT EXISTS idx_challenges_category ON challenges(category);
+CREATE INDEX IF NOT EXISTS idx_challenge_tags_tag ON challenge_tags(tag);
+CREATE INDEX IF NOT EXISTS idx_challenges_unlock_code ON challenges(unlock_code);
+CREATE INDEX IF NOT EXISTS idx_unlock_attempts_user_id ON unlock_attempts(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_challenge_placements_post_id ON challenge_placements(post_id);
+CREATE INDEX IF NOT EXISTS idx_challenge_votes_post_id ON challenge_votes(post_id);
+CREATE INDEX IF NOT EXISTS idx_posts_media_hash ON posts(media_hash);
+CREATE INDEX IF NOT EXISTS idx_moderation_actions_post_id ON moderation_actions(post_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events(target_type, target_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_created_at ON audit_events(created_at);
 
 CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
 CREATE INDEX IF NOT EXISTS idx_posts_challenge_id ON posts(challenge_id);
 CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_posts_revoked ON posts(revoked);
 
-CREATE INDEX IF NOT EXISTS idx_likes_post_id ON likes(post_id);
-CREATE INDEX IF NOT EXISTS idx_likes_user_id ON likes(user_id);
+CREATE INDEX IF NOT EXISTS idx_reactions_post_id ON reactions(post_id);
+CREATE INDEX IF NOT EXISTS idx_reactions_user_id ON reactions(user_id);
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows(followee_id);
 
 CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
 CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments(user_id);
 CREATE INDEX IF NOT EXISTS idx_comments_created_at ON comments(created_at);
+CREATE INDEX IF NOT EXISTS idx_comment_edits_comment_id ON comment_edits(comment_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_mentions_post_id ON mentions(post_id);
+CREATE INDEX IF NOT EXISTS idx_mentions_comment_id ON mentions(comment_id);
+CREATE INDEX IF NOT EXISTS idx_hashtags_tag ON hashtags(tag, created_at);
+CREATE INDEX IF NOT EXISTS idx_hashtags_post_id ON hashtags(post_id);
+CREATE INDEX IF NOT EXISTS idx_hashtags_comment_id ON hashtags(comment_id);
 
 CREATE INDEX IF NOT EXISTS idx_temp_media_expires_at ON temp_media(expires_at);
 CREATE INDEX IF NOT EXISTS idx_temp_media_user_id ON temp_media(user_id);
@@ -110,10 +346,15 @@ CREATE INDEX IF NOT EXISTS idx_temp_media_user_id ON temp_media(user_id);
 CREATE INDEX IF NOT EXISTS idx_submissions_challenge_id ON challenge_submissions(challenge_id);
 CREATE INDEX IF NOT EXISTS idx_submissions_user_id ON challenge_submissions(user_id);
 
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, created_at);
+
 CREATE INDEX IF NOT EXISTS idx_users_username ON users(username);
 CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
 CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);
 
+-- Full-text search is SQLite-only: the backend builds an FTS5 index at
+-- startup (backend/internal/database/search.go) and has no Postgres search path
+
 -- Add foreign key constraint for completed_post_id (deferred to avoid circular dependency)
 ALTER TABLE challenges 
 ADD CONSTRAINT fk_challenges_completed_post_id 
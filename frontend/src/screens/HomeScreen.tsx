@@ -99,7 +99,7 @@ const HomeScreen: React.FC<Props> = ({ navigation }) => {
   const [isLoading, setIsLoading] = useState(true);
   const [isLoadingMore, setIsLoadingMore] = useState(false);
   const [isRefreshing, setIsRefreshing] = useState(false);
-  const [page, setPage] = useState(1);
+  const [nextCursor, setNextCursor] = useState<string | null>(null);
   const [hasMore, setHasMore] = useState(true);
   const [visibleVideoId, setVisibleVideoId] = useState<number | null>(null);
   const [savedVisibleVideoId, setSavedVisibleVideoId] = useState<number | null>(null);
@@ -107,25 +107,24 @@ const HomeScreen: React.FC<Props> = ({ navigation }) => {
   const { showError } = useAlert();
   const isFocused = useIsFocused();
 
-  const loadPosts = async (pageNum: number = 1, isRefresh: boolean = false) => {
+  const loadPosts = async (cursor: string | null = null, isRefresh: boolean = false) => {
     try {
-      const newPosts = await apiService.getFeed(pageNum, 20);
+      const feed = await apiService.getFeed(cursor, 20);
       
       // Filter out any invalid posts that don't have IDs
-      const validPosts = (newPosts || []).filter(post => post && post.id);
+      const validPosts = (feed.posts || []).filter(post => post && post.id);
       
-      if (pageNum === 1 || isRefresh) {
+      if (!cursor || isRefresh) {
         setPosts(validPosts);
-        setPage(1);
       } else {
         setPosts(prev => [...prev, ...validPosts]);
-        setPage(pageNum);
       }
       
-      // If we got fewer posts than requested, we've reached the end
-      setHasMore(validPosts.length === 20);
+      // The last page comes without a cursor
+      setNextCursor(feed.next_cursor);
+      setHasMore(feed.next_cursor !== null);
     } catch (error: any) {
-      if (pageNum === 1 || isRefresh) {
+      if (!cursor || isRefresh) {
         showError('Failed to load posts');
       } else {
         showError('Failed to load more posts');
@@ -137,7 +136,7 @@ const HomeScreen: React.FC<Props> = ({ navigation }) => {
 
   useFocusEffect(
     useCallback(() => {
-      loadPosts(1, true).finally(() => setIsLoading(false));
+      loadPosts(null, true).finally(() => setIsLoading(false));
     }, [])
   );
 
@@ -160,10 +159,10 @@ const HomeScreen: React.FC<Props> = ({ navigation }) => {
 
   const handleRefresh = async () => {
     setIsRefreshing(true);
-    setHasMore(true); // Reset pagination on refresh\n    setPage(1); // Reset page counter
+    setHasMore(true); // Reset pagination on refresh
     
     try {
-      await loadPosts(1, true);
+      await loadPosts(null, true);
     } catch (error) {
       // Error already handled in loadPosts
     } finally {
@@ -174,7 +173,7 @@ const HomeScreen: React.FC<Props> = ({ navigation }) => {
   const handleLoadMore = () => {
     if (hasMore && !isLoadingMore && !isRefreshing) {
       setIsLoadingMore(true);
-      loadPosts(page + 1).finally(() => setIsLoadingMore(false));
+      loadPosts(nextCursor).finally(() => setIsLoadingMore(false));
     }
   };
 
@@ -30,6 +30,9 @@ interface Props {
   navigation: any;
 }
 
+// Comments and replies come a page at a time, oldest first
+const COMMENTS_PAGE_SIZE = 20;
+
 // Video Detail Component for handling expo-video hooks
 const VideoDetail: React.FC<{ mediaUrl: string }> = ({ mediaUrl }) => {
   const player = useVideoPlayer(mediaUrl, (player) => {
@@ -93,8 +96,15 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
   const [newComment, setNewComment] = useState('');
   const [isLoading, setIsLoading] = useState(true);
   const [isLoadingComments, setIsLoadingComments] = useState(true);
+  const [commentsPage, setCommentsPage] = useState(1);
+  const [hasMoreComments, setHasMoreComments] = useState(false);
+  const [isLoadingMoreComments, setIsLoadingMoreComments] = useState(false);
+  const [replies, setReplies] = useState<Record<number, Comment[]>>({});
+  const [hasMoreReplies, setHasMoreReplies] = useState<Record<number, boolean>>({});
+  const [loadingReplies, setLoadingReplies] = useState<Record<number, boolean>>({});
   const [isSubmittingComment, setIsSubmittingComment] = useState(false);
   const [showCommentInput, setShowCommentInput] = useState(false);
+  const [revokeReason, setRevokeReason] = useState('');
   const { showError, showSuccess } = useAlert();
   const { confirm, ConfirmComponent } = useConfirm();
 
@@ -115,16 +125,38 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
     }
   };
 
-  const loadComments = async () => {
+  const loadComments = async (page = 1) => {
+    if (page > 1) setIsLoadingMoreComments(true);
     try {
-      const data = await apiService.getComments(postId);
+      const data = await apiService.getComments(postId, page);
       
       // Filter out any invalid comments that don't have IDs
       const validComments = (data || []).filter(comment => comment && comment.id);
-      setComments(validComments);
+      setComments(prev => page === 1 ? validComments : [...prev, ...validComments]);
+      setCommentsPage(page);
+      setHasMoreComments((data || []).length === COMMENTS_PAGE_SIZE);
     } catch (error: any) {
+      if (page > 1) showError('Failed to load more comments');
     } finally {
       setIsLoadingComments(false);
+      setIsLoadingMoreComments(false);
+    }
+  };
+
+  const loadReplies = async (commentId: number) => {
+    const loaded = replies[commentId] || [];
+    const page = Math.floor(loaded.length / COMMENTS_PAGE_SIZE) + 1;
+
+    setLoadingReplies(prev => ({ ...prev, [commentId]: true }));
+    try {
+      const data = (await apiService.getCommentReplies(commentId, page)) || [];
+      const validReplies = data.filter(reply => reply && reply.id);
+      setReplies(prev => ({ ...prev, [commentId]: [...(prev[commentId] || []), ...validReplies] }));
+      setHasMoreReplies(prev => ({ ...prev, [commentId]: data.length === COMMENTS_PAGE_SIZE }));
+    } catch (error: any) {
+      showError('Failed to load replies');
+    } finally {
+      setLoadingReplies(prev => ({ ...prev, [commentId]: false }));
     }
   };
 
@@ -158,7 +190,10 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
     setIsSubmittingComment(true);
     try {
       const comment = await apiService.createComment(postId, newComment.trim());
-      setComments(prev => [...prev, comment]);
+      // With more pages to load, the new comment shows up at the end of them
+      if (!hasMoreComments) {
+        setComments(prev => [...prev, comment]);
+      }
       setNewComment('');
       setShowCommentInput(false);
     } catch (error: any) {
@@ -171,9 +206,16 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
   const handleRevokePoints = async () => {
     if (!post) return;
 
+    if (!revokeReason.trim()) {
+      showError('Enter a reason for revoking the points');
+      return;
+    }
+
     const confirmed = await confirm({
       title: 'Revoke Points',
-      message: 'Are you sure you want to revoke points from this post? The challenge will be returned to the available pool for any user to pick up.',
+      message: post.challenge_type === 'open'
+        ? 'Are you sure you want to revoke points from this post? Other placements on the challenge are kept.'
+        : 'Are you sure you want to revoke points from this post? The challenge will be returned to the available pool for any user to pick up.',
       confirmText: 'Revoke',
       cancelText: 'Cancel',
       confirmColor: '#dc3545'
@@ -181,8 +223,8 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
 
     if (confirmed) {
       try {
-        await apiService.revokePostPoints(post.id);
-        showSuccess('Points revoked successfully. The challenge has been returned to the available pool.');
+        const result = await apiService.revokePostPoints(post.id, revokeReason.trim());
+        showSuccess(result.message);
         navigation.goBack();
       } catch (error: any) {
         showError(error.message || 'Failed to revoke points');
@@ -190,28 +232,60 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
     }
   };
 
-  const renderComment = (item: Comment) => (
-    <View key={item.id} style={styles.commentItem}>
-      <View style={styles.commentAvatar}>
-        {item.user_profile_image ? (
-          <Image 
-            source={{ uri: `${apiService.getMediaUrl(item.user_profile_image)}?t=${Date.now()}` }}
-            style={styles.commentAvatarImage}
-            resizeMode="cover"
-          />
-        ) : (
-          <Ionicons name="person" size={16} color={MagicalTheme.colors.textMuted} />
+  const renderReplies = (item: Comment) => {
+    const loaded = replies[item.id] || [];
+    const replyCount = item.reply_count || 0;
+    const canLoadMore = loaded.length === 0 ? replyCount > 0 : hasMoreReplies[item.id];
+
+    return (
+      <>
+        {loaded.map(reply => renderComment(reply))}
+        {canLoadMore && (
+          <Pressable style={styles.repliesButton} onPress={() => loadReplies(item.id)} disabled={loadingReplies[item.id]}>
+            {loadingReplies[item.id] ? (
+              <ActivityIndicator size="small" color={MagicalTheme.colors.royalBlue} />
+            ) : (
+              <Text style={styles.repliesButtonText}>
+                {loaded.length === 0
+                  ? `View ${replyCount} ${replyCount === 1 ? 'reply' : 'replies'}`
+                  : 'View more replies'}
+              </Text>
+            )}
+          </Pressable>
         )}
-      </View>
-      <View style={styles.commentContent}>
-        <View style={styles.commentHeader}>
-          <Text style={styles.commentUsername}>{item.username}</Text>
-          <Text style={styles.commentTime}>
-            {formatRelativeTime(item.created_at)}
-          </Text>
+      </>
+    );
+  };
+
+  const renderComment = (item: Comment) => (
+    <View key={item.id} style={item.parent_id ? styles.replyThread : undefined}>
+      <View style={styles.commentItem}>
+        <View style={styles.commentAvatar}>
+          {item.user_profile_image ? (
+            <Image 
+              source={{ uri: `${apiService.getMediaUrl(item.user_profile_image)}?t=${Date.now()}` }}
+              style={styles.commentAvatarImage}
+              resizeMode="cover"
+            />
+          ) : (
+            <Ionicons name="person" size={16} color={MagicalTheme.colors.textMuted} />
+          )}
+        </View>
+        <View style={styles.commentContent}>
+          <View style={styles.commentHeader}>
+            <Text style={styles.commentUsername}>{item.username}</Text>
+            <Text style={styles.commentTime}>
+              {formatRelativeTime(item.created_at)}
+            </Text>
+          </View>
+          {item.deleted ? (
+            <Text style={[styles.commentText, styles.commentDeleted]}>Comment deleted</Text>
+          ) : (
+            <Text style={styles.commentText}>{item.content}</Text>
+          )}
         </View>
-        <Text style={styles.commentText}>{item.content}</Text>
       </View>
+      {renderReplies(item)}
     </View>
   );
 
@@ -330,6 +404,16 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
           </View>
         </View>
         
+        {user?.role === 'admin' && !post.revoked && (
+          <TextInput
+            style={[styles.textInput, styles.revokeReasonInput]}
+            value={revokeReason}
+            onChangeText={setRevokeReason}
+            placeholder="Reason for revoking points"
+            maxLength={200}
+          />
+        )}
+
         {post.caption && (
           <View style={styles.captionSection}>
             <Text style={styles.caption}>{post.caption}</Text>
@@ -350,6 +434,20 @@ const PostDetailScreen: React.FC<Props> = ({ route, navigation }) => {
           ) : (
             <Text style={styles.noComments}>No comments yet</Text>
           )}
+
+          {hasMoreComments && (
+            <Pressable
+              style={styles.moreCommentsButton}
+              onPress={() => loadComments(commentsPage + 1)}
+              disabled={isLoadingMoreComments}
+            >
+              {isLoadingMoreComments ? (
+                <ActivityIndicator size="small" color={MagicalTheme.colors.royalBlue} />
+              ) : (
+                <Text style={styles.repliesButtonText}>Load more comments</Text>
+              )}
+            </Pressable>
+          )}
         </View>
       </ScrollView>
 
@@ -656,6 +754,27 @@ const styles = StyleSheet.create({
     fontSize: MagicalTheme.typography.tiny,
     color: MagicalTheme.colors.textMuted,
   },
+  commentDeleted: {
+    fontStyle: 'italic',
+    color: MagicalTheme.colors.textMuted,
+  },
+  replyThread: {
+    marginLeft: 40,
+  },
+  repliesButton: {
+    paddingVertical: 8,
+    paddingLeft: 40,
+    alignItems: 'flex-start',
+  },
+  repliesButtonText: {
+    fontSize: MagicalTheme.typography.small,
+    fontWeight: MagicalTheme.typography.weights.semibold,
+    color: MagicalTheme.colors.royalBlue,
+  },
+  moreCommentsButton: {
+    paddingVertical: MagicalTheme.spacing.md,
+    alignItems: 'center',
+  },
   noComments: {
     fontSize: MagicalTheme.typography.caption,
     color: MagicalTheme.colors.textMuted,
@@ -732,6 +851,11 @@ const styles = StyleSheet.create({
     marginLeft: MagicalTheme.spacing.sm,
     backgroundColor: MagicalTheme.colors.surfaceSecondary,
   },
+  revokeReasonInput: {
+    flex: 0,
+    marginHorizontal: MagicalTheme.spacing.md,
+    marginBottom: MagicalTheme.spacing.sm,
+  },
   revokeButton: {
     flexDirection: 'row',
     alignItems: 'center',
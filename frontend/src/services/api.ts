@@ -1,4 +1,4 @@
-import { AuthResponse, LoginRequest, RegisterRequest, User, Challenge, Post, Comment, ApiError } from '../types';
+import { AuthResponse, LoginRequest, RegisterRequest, User, Challenge, Post, Comment, TrendingTag, Reaction, PostReactions, FeedPage, FeedFilters, FollowList, SearchResults, SearchResultType, ApiError } from '../types';
 import storage from '../utils/storage';
 
 // Get API base URL from environment variables
@@ -112,6 +112,32 @@ class ApiService {
     return this.makeRequest<User>(`/users/${userId}`);
   }
 
+  async getUserPosts(userId: number, cursor?: string | null, limit: number = 20): Promise<FeedPage> {
+    const params = new URLSearchParams({ limit: String(limit) });
+    if (cursor) params.set('cursor', cursor);
+    return this.makeRequest<FeedPage>(`/users/${userId}/posts?${params.toString()}`);
+  }
+
+  async followUser(userId: number): Promise<{ message: string }> {
+    return this.makeRequest<{ message: string }>(`/users/${userId}/follow`, {
+      method: 'POST',
+    });
+  }
+
+  async unfollowUser(userId: number): Promise<{ message: string }> {
+    return this.makeRequest<{ message: string }>(`/users/${userId}/follow`, {
+      method: 'DELETE',
+    });
+  }
+
+  async getFollowers(userId: number, page = 1): Promise<FollowList> {
+    return this.makeRequest<FollowList>(`/users/${userId}/followers?page=${page}`);
+  }
+
+  async getFollowing(userId: number, page = 1): Promise<FollowList> {
+    return this.makeRequest<FollowList>(`/users/${userId}/following?page=${page}`);
+  }
+
   // Challenges
   async getChallenges(): Promise<Challenge[]> {
     return this.makeRequest<Challenge[]>('/challenges');
@@ -240,8 +266,29 @@ class ApiService {
   }
 
   // Feed
-  async getFeed(page: number = 1, limit: number = 20): Promise<Post[]> {
-    return this.makeRequest<Post[]>(`/feed?page=${page}&limit=${limit}`);
+  async getFeed(cursor?: string | null, limit: number = 20, filters: FeedFilters = {}): Promise<FeedPage> {
+    const params = new URLSearchParams({ limit: String(limit) });
+    if (cursor) params.set('cursor', cursor);
+    Object.entries(filters).forEach(([key, value]) => {
+      if (value !== undefined && value !== '') params.set(key, String(value));
+    });
+    return this.makeRequest<FeedPage>(`/feed?${params.toString()}`);
+  }
+
+  async getTopPosts(period: 'today' | 'trip' = 'today', cursor?: string | null, limit: number = 10): Promise<FeedPage> {
+    const params = new URLSearchParams({ period, limit: String(limit) });
+    if (cursor) params.set('cursor', cursor);
+    return this.makeRequest<FeedPage>(`/feed/top?${params.toString()}`);
+  }
+
+  async search(q: string, page: number = 1, type?: SearchResultType): Promise<SearchResults> {
+    const params = new URLSearchParams({ q, page: String(page) });
+    if (type) params.set('type', type);
+    return this.makeRequest<SearchResults>(`/search?${params.toString()}`);
+  }
+
+  async getTrendingTags(hours?: number): Promise<TrendingTag[]> {
+    return this.makeRequest<TrendingTag[]>(`/feed/tags/trending${hours ? `?hours=${hours}` : ''}`);
   }
 
   async getPost(postId: number): Promise<Post> {
@@ -254,9 +301,10 @@ class ApiService {
     });
   }
 
-  async revokePostPoints(postId: number): Promise<{ message: string }> {
+  async revokePostPoints(postId: number, reason: string, reaward: boolean = false): Promise<{ message: string }> {
     return this.makeRequest<{ message: string }>(`/admin/posts/${postId}/revoke`, {
       method: 'POST',
+      body: JSON.stringify({ reason, reaward }),
     });
   }
 
@@ -273,17 +321,52 @@ class ApiService {
     });
   }
 
-  async getComments(postId: number): Promise<Comment[]> {
-    return this.makeRequest<Comment[]>(`/posts/${postId}/comments`);
+  async getPostReactions(postId: number, page = 1, reaction?: Reaction): Promise<PostReactions> {
+    const reactionParam = reaction ? `&reaction=${reaction}` : '';
+    return this.makeRequest<PostReactions>(`/posts/${postId}/likes?page=${page}${reactionParam}`);
+  }
+
+  async setReaction(postId: number, reaction: Reaction): Promise<{ message: string; reaction: Reaction }> {
+    return this.makeRequest<{ message: string; reaction: Reaction }>(`/posts/${postId}/reaction`, {
+      method: 'PUT',
+      body: JSON.stringify({ reaction }),
+    });
+  }
+
+  async removeReaction(postId: number): Promise<void> {
+    return this.makeRequest<void>(`/posts/${postId}/reaction`, {
+      method: 'DELETE',
+    });
+  }
+
+  async getComments(postId: number, page = 1): Promise<Comment[]> {
+    return this.makeRequest<Comment[]>(`/posts/${postId}/comments?page=${page}`);
   }
 
-  async createComment(postId: number, content: string): Promise<Comment> {
+  async createComment(postId: number, content: string, parentId?: number): Promise<Comment> {
     return this.makeRequest<Comment>(`/posts/${postId}/comments`, {
       method: 'POST',
+      body: JSON.stringify({ content, parent_id: parentId }),
+    });
+  }
+
+  async getCommentReplies(commentId: number, page = 1): Promise<Comment[]> {
+    return this.makeRequest<Comment[]>(`/comments/${commentId}/replies?page=${page}`);
+  }
+
+  async updateComment(commentId: number, content: string): Promise<Comment> {
+    return this.makeRequest<Comment>(`/comments/${commentId}`, {
+      method: 'PUT',
       body: JSON.stringify({ content }),
     });
   }
 
+  async deleteComment(commentId: number): Promise<void> {
+    return this.makeRequest<void>(`/comments/${commentId}`, {
+      method: 'DELETE',
+    });
+  }
+
   // Leaderboard
   async getLeaderboard(): Promise<User[]> {
     return this.makeRequest<User[]>('/leaderboard');
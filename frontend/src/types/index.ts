@@ -8,6 +8,25 @@ export interface User {
   total_points: number;
   challenges_completed: number;
   created_at: string;
+  posts_by_challenge_type?: { exclusive: number; open: number };
+  followers_count?: number;
+  following_count?: number;
+  is_following?: boolean;
+}
+
+// A player in a follower or following list
+export interface FollowEntry {
+  user_id: number;
+  username: string;
+  profile_image?: string;
+  followed_at: string;
+}
+
+export interface FollowList {
+  users: FollowEntry[];
+  page: number;
+  limit: number;
+  total: number;
 }
 
 export interface Challenge {
@@ -47,6 +66,7 @@ export interface Post {
   caption?: string;
   created_at: string;
   revoked: boolean;
+  revoke_reason?: string;
   username?: string;
   user_profile_image?: string;
   challenge_title?: string;
@@ -57,16 +77,100 @@ export interface Post {
   likes_count?: number;
   comments_count?: number;
   user_liked?: boolean;
+  mentions?: Mention[];
+  reactions?: Partial<Record<Reaction, number>>;
+  user_reaction?: Reaction;
+  score?: number;
+}
+
+// Reactions players can leave on a post, one per player:
+// ❤️ heart, 😂 laugh, 🔥 fire, 😍 love, 👏 clap, 😮 wow
+export type Reaction = 'heart' | 'laugh' | 'fire' | 'love' | 'clap' | 'wow';
+
+// A player in a post's who-liked list; created_at is missing for old likes
+export interface PostReaction {
+  user_id: number;
+  username: string;
+  user_profile_image?: string;
+  reaction: Reaction;
+  created_at: string | null;
+}
+
+export interface PostReactions {
+  reactions: PostReaction[];
+  counts: Partial<Record<Reaction, number>>;
+  page: number;
+  limit: number;
+  total: number;
+}
+
+export type SearchResultType = 'challenge' | 'post' | 'comment' | 'user';
+
+// One search match; snippet is HTML-escaped with the matching words in <mark>
+export interface SearchResult {
+  type: SearchResultType;
+  id: number;
+  post_id?: number;
+  title: string;
+  snippet: string;
+  score: number;
+}
+
+export interface SearchResults {
+  results: SearchResult[];
+  page: number;
+  limit: number;
+  total: number;
+}
+
+// One page of the feed; pass next_cursor back to load the page after it
+export interface FeedPage {
+  posts: Post[];
+  next_cursor: string | null;
+}
+
+export interface FeedFilters {
+  tag?: string;
+  user_id?: number;
+  challenge_id?: number;
+  media_type?: 'photo' | 'video';
+  challenge_type?: 'exclusive' | 'open';
+  exclude_revoked?: boolean;
+  scope?: 'all' | 'following';
+  sort?: 'new' | 'top';
+}
+
+// An @username in a caption or comment; start and end are UTF-16 offsets
+// into the text, so text.slice(start, end) is the mention
+export interface Mention {
+  user_id: number;
+  username: string;
+  start: number;
+  end: number;
+}
+
+// A hashtag with how often it was used in the trending window
+export interface TrendingTag {
+  tag: string;
+  uses: number;
+  posts: number;
 }
 
 export interface Comment {
   id: number;
   user_id: number;
   post_id: number;
+  parent_id?: number;
+  depth?: number;
   content: string;
   created_at: string;
+  edited?: boolean;
+  edited_at?: string;
+  deleted?: boolean;
+  reply_count?: number;
   username?: string;
   user_profile_image?: string;
+  mentions?: Mention[];
 }
 
 export interface AuthResponse {